@@ -0,0 +1,418 @@
+package streamdeck
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultGIFDelay is used for GIF frames without (or with an unreasonably
+// short) delay, like most browsers do.
+const defaultGIFDelay = 100 * time.Millisecond
+
+// Animation is a sequence of frames played on a key. All animations of a
+// Stream Deck are played by one shared scheduler.
+type Animation struct {
+	key    int
+	frames [][]byte
+	delays []time.Duration
+	plays  int // number of times the sequence is played, 0 is forever
+
+	// guarded by the lock of the animator
+	frame  int
+	played int
+	next   time.Time
+	paused bool
+	ended  bool
+
+	a    *animator
+	done chan struct{}
+}
+
+// Key returns the key the animation is played on.
+func (an *Animation) Key() int {
+	return an.key
+}
+
+// Done returns a channel which is closed once the animation has ended or
+// has been stopped.
+func (an *Animation) Done() <-chan struct{} {
+	return an.done
+}
+
+// Stop ends the animation. The key keeps showing the current frame. Once
+// Stop returns, no further frame is written, so the key can be filled with
+// another image right away.
+func (an *Animation) Stop() {
+	an.a.lock.Lock()
+	an.a.endInLock(an)
+	an.a.lock.Unlock()
+	an.a.notify()
+	an.a.waitCommit()
+}
+
+// Pause holds the animation on the current frame.
+func (an *Animation) Pause() {
+	an.a.lock.Lock()
+	an.paused = true
+	an.a.lock.Unlock()
+	an.a.notify()
+}
+
+// Resume continues a paused animation with the next frame.
+func (an *Animation) Resume() {
+	an.a.lock.Lock()
+	if an.paused {
+		an.paused = false
+		an.next = time.Now()
+	}
+	an.a.lock.Unlock()
+	an.a.notify()
+}
+
+// animator is the scheduler playing the animations of a Stream Deck. Its
+// go routine only runs while there are animations.
+type animator struct {
+	sd   *StreamDeck
+	lock sync.Mutex
+	// commit is held while frames are written, so that stopping can wait
+	// for a frame in flight
+	commit  sync.Mutex
+	anims   map[int]*Animation
+	running bool
+	closed  bool // stopAll has been called
+	changed chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newAnimator(sd *StreamDeck) *animator {
+	return &animator{
+		sd:      sd,
+		anims:   map[int]*Animation{},
+		changed: make(chan struct{}, 1),
+	}
+}
+
+// PlayAnimation plays a sequence of frames on a key. Each frame is shown
+// for the corresponding delay. If loop is true, the sequence is repeated
+// until the animation is stopped. The frames are scaled (if necessary) and
+// encoded once upfront. An animation already playing on the key is stopped.
+// Once the Stream Deck is being closed, ErrDisconnected is returned.
+// Filling the key with FillImage (or any method based on it), a Batch,
+// FillPanel, a PanelStreamer or the Renderer stops the animation as well.
+func (sd *StreamDeck) PlayAnimation(key int, frames []image.Image, delays []time.Duration, loop bool) (*Animation, error) {
+	plays := 1
+	if loop {
+		plays = 0
+	}
+	return sd.playAnimation(key, frames, delays, plays)
+}
+
+// PlayGIFFromFile plays an animated GIF on a key, honouring the frame
+// delays, the disposal methods and the loop count of the file.
+func (sd *StreamDeck) PlayGIFFromFile(key int, path string) (*Animation, error) {
+	reader, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	frames, delays, plays, err := decodeGIF(reader)
+	if err != nil {
+		return nil, err
+	}
+	return sd.playAnimation(key, frames, delays, plays)
+}
+
+// StopAnimation stops the animation playing on a key (if any). Like
+// Animation.Stop, it waits for a frame which is currently written.
+func (sd *StreamDeck) StopAnimation(key int) {
+	sd.stopAnimations(key)
+}
+
+// stopAnimations stops the animations playing on the keys. It must not be
+// called while holding sd.lock.
+func (sd *StreamDeck) stopAnimations(keys ...int) {
+	sd.lock.Lock()
+	a := sd.animator
+	sd.lock.Unlock()
+	if a == nil {
+		return
+	}
+
+	a.lock.Lock()
+	stopped := false
+	for _, key := range keys {
+		if an, ok := a.anims[key]; ok {
+			a.endInLock(an)
+			stopped = true
+		}
+	}
+	a.lock.Unlock()
+	if stopped {
+		a.notify()
+		a.waitCommit()
+	}
+}
+
+func (sd *StreamDeck) playAnimation(key int, frames []image.Image, delays []time.Duration, plays int) (*Animation, error) {
+	if err := sd.checkValidKeyIndex(key); err != nil {
+		return nil, err
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("animation without frames")
+	}
+	if len(delays) != len(frames) {
+		return nil, fmt.Errorf("animation has %d frames but %d delays", len(frames), len(delays))
+	}
+
+	encoded := make([][]byte, 0, len(frames))
+	for i, img := range frames {
+		if img.Bounds().Dx() != sd.Config.ButtonSize {
+			img = resize(img, sd.Config.ButtonSize, sd.Config.ButtonSize)
+		}
+		imgBuf, err := sd.encodeImage(img)
+		if err != nil {
+			return nil, fmt.Errorf("frame %d: %w", i, err)
+		}
+		encoded = append(encoded, imgBuf)
+	}
+
+	sd.lock.Lock()
+	if sd.closing {
+		sd.lock.Unlock()
+		return nil, ErrDisconnected
+	}
+	if sd.animator == nil {
+		sd.animator = newAnimator(sd)
+	}
+	a := sd.animator
+	sd.lock.Unlock()
+
+	an := &Animation{
+		key:    key,
+		frames: encoded,
+		delays: delays,
+		plays:  plays,
+		next:   time.Now(),
+		a:      a,
+		done:   make(chan struct{}),
+	}
+
+	a.lock.Lock()
+	if a.closed {
+		a.lock.Unlock()
+		return nil, ErrDisconnected
+	}
+	if old, ok := a.anims[key]; ok {
+		a.endInLock(old)
+	}
+	a.anims[key] = an
+	if !a.running {
+		a.running = true
+		a.wg.Add(1)
+		go a.run()
+	}
+	a.lock.Unlock()
+	a.notify()
+
+	return an, nil
+}
+
+// waitCommit waits until the frames currently written (if any) have been
+// committed. Later commits skip ended animations.
+func (a *animator) waitCommit() {
+	a.commit.Lock()
+	a.commit.Unlock()
+}
+
+func (a *animator) notify() {
+	select {
+	case a.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (a *animator) endInLock(an *Animation) {
+	if an.ended {
+		return
+	}
+	an.ended = true
+	if a.anims[an.key] == an {
+		delete(a.anims, an.key)
+	}
+	close(an.done)
+}
+
+// stopAll stops all animations and waits for the scheduler to terminate.
+// No animations can be started afterwards.
+func (a *animator) stopAll() {
+	a.lock.Lock()
+	a.closed = true
+	for _, an := range a.anims {
+		a.endInLock(an)
+	}
+	a.lock.Unlock()
+	a.notify()
+	a.wg.Wait()
+}
+
+func (a *animator) run() {
+	defer a.wg.Done()
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		a.lock.Lock()
+		if len(a.anims) == 0 {
+			a.running = false
+			a.lock.Unlock()
+			return
+		}
+
+		now := time.Now()
+		due := map[*Animation][]byte{}
+		var finished []*Animation
+		var next time.Time
+
+		for _, an := range a.anims {
+			if an.paused {
+				continue
+			}
+			if !now.Before(an.next) {
+				due[an] = an.frames[an.frame]
+				// don't try to catch up if the scheduler fell behind
+				an.next = an.next.Add(an.delays[an.frame])
+				if an.next.Before(now) {
+					an.next = now.Add(an.delays[an.frame])
+				}
+				an.frame++
+				if an.frame == len(an.frames) {
+					an.frame = 0
+					an.played++
+					if an.plays > 0 && an.played >= an.plays {
+						finished = append(finished, an)
+						continue
+					}
+				}
+			}
+			if next.IsZero() || an.next.Before(next) {
+				next = an.next
+			}
+		}
+		a.lock.Unlock()
+
+		if len(due) > 0 {
+			a.commitFrames(due)
+		}
+
+		// the last frame has been shown
+		if len(finished) > 0 {
+			a.lock.Lock()
+			for _, an := range finished {
+				a.endInLock(an)
+			}
+			a.lock.Unlock()
+			continue
+		}
+
+		wait := time.Hour
+		if !next.IsZero() {
+			wait = time.Until(next)
+		}
+		timer.Reset(wait)
+		select {
+		case <-timer.C:
+		case <-a.changed:
+			timer.Stop()
+		}
+	}
+}
+
+// commitFrames writes the frames which were due. Animations which have
+// been stopped in the meantime are skipped, so that their key isn't
+// overwritten after Stop returned.
+func (a *animator) commitFrames(due map[*Animation][]byte) {
+	a.commit.Lock()
+	defer a.commit.Unlock()
+
+	images := map[int][]byte{}
+	a.lock.Lock()
+	for an, frame := range due {
+		if !an.ended {
+			images[an.key] = frame
+		}
+	}
+	a.lock.Unlock()
+
+	for _, err := range a.sd.commitImages(images) {
+		debug("animation frame: %v", err)
+	}
+}
+
+// decodeGIF decodes all frames of a GIF, composited according to their
+// disposal methods, together with their delays and the number of plays
+// (0 is forever).
+func decodeGIF(r io.Reader) ([]image.Image, []time.Duration, int, error) {
+	g, err := gif.DecodeAll(r)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() && len(g.Image) > 0 {
+		bounds = g.Image[0].Bounds()
+	}
+	canvas := image.NewRGBA(bounds)
+
+	frames := make([]image.Image, 0, len(g.Image))
+	delays := make([]time.Duration, 0, len(g.Image))
+
+	for i, frame := range g.Image {
+		var previous *image.RGBA
+		disposal := byte(0)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewRGBA(bounds)
+			draw.Draw(previous, bounds, canvas, bounds.Min, draw.Src)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+		img := image.NewRGBA(bounds)
+		draw.Draw(img, bounds, canvas, bounds.Min, draw.Src)
+		frames = append(frames, img)
+
+		delay := defaultGIFDelay
+		if i < len(g.Delay) && g.Delay[i] > 1 {
+			delay = time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		}
+		delays = append(delays, delay)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+
+	// LoopCount 0 loops forever, -1 plays once and n plays n+1 times
+	plays := 0
+	switch {
+	case g.LoopCount < 0:
+		plays = 1
+	case g.LoopCount > 0:
+		plays = g.LoopCount + 1
+	}
+
+	return frames, delays, plays, nil
+}
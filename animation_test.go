@@ -0,0 +1,207 @@
+package streamdeck
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+)
+
+func waitDone(t *testing.T, an *Animation) {
+	t.Helper()
+	select {
+	case <-an.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("animation did not end")
+	}
+}
+
+func TestPlayAnimationOnce(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+
+	colors := []color.Color{
+		color.RGBA{255, 0, 0, 255},
+		color.RGBA{0, 255, 0, 255},
+		color.RGBA{0, 0, 255, 255},
+	}
+	frames := []image.Image{}
+	for _, c := range colors {
+		frames = append(frames, solidImage(72, c))
+	}
+	delays := []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond}
+
+	_, err := sd.PlayAnimation(0, frames, delays[:2], false)
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = sd.PlayAnimation(99, frames, delays, false)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	an, err := sd.PlayAnimation(4, frames, delays, false)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, an.Key(), test.ShouldEqual, 4)
+	waitDone(t, an)
+
+	// every frame is written once, in order
+	written := ft.Written()
+	test.That(t, len(written), test.ShouldEqual, len(frames))
+	for i, f := range frames {
+		want, err := sd.encodeImage(f)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, written[i][2], test.ShouldEqual, 4)
+		test.That(t, written[i][8:8+len(want)], test.ShouldResemble, want)
+	}
+}
+
+func TestAnimationPauseResumeStop(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+
+	frames := []image.Image{
+		solidImage(72, color.RGBA{255, 0, 0, 255}),
+		solidImage(72, color.RGBA{0, 255, 0, 255}),
+	}
+	delays := []time.Duration{time.Hour, time.Hour}
+
+	an1, err := sd.PlayAnimation(1, frames, delays, true)
+	test.That(t, err, test.ShouldBeNil)
+	an2, err := sd.PlayAnimation(2, frames, delays, true)
+	test.That(t, err, test.ShouldBeNil)
+
+	for len(ft.Written()) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	test.That(t, writtenKeys(ft), test.ShouldResemble, map[int]bool{1: true, 2: true})
+	ft.Reset()
+
+	// resuming shows the next frame right away
+	an1.Pause()
+	an1.Resume()
+	for len(ft.Written()) < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	test.That(t, writtenKeys(ft), test.ShouldResemble, map[int]bool{1: true})
+
+	an1.Stop()
+	waitDone(t, an1)
+	sd.StopAnimation(2)
+	waitDone(t, an2)
+
+	// the shared scheduler terminates without animations
+	sd.animator.wg.Wait()
+	test.That(t, sd.animator.running, test.ShouldBeFalse)
+}
+
+func TestStillImageAfterStop(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+
+	frames := []image.Image{
+		solidImage(72, color.RGBA{255, 0, 0, 255}),
+		solidImage(72, color.RGBA{0, 255, 0, 255}),
+	}
+	delays := []time.Duration{time.Microsecond, time.Microsecond}
+	still := solidImage(72, color.RGBA{0, 0, 255, 255})
+	want, err := sd.encodeImage(still)
+	test.That(t, err, test.ShouldBeNil)
+
+	// no frame is written after Stop returned
+	for i := 0; i < 20; i++ {
+		an, err := sd.PlayAnimation(3, frames, delays, true)
+		test.That(t, err, test.ShouldBeNil)
+		time.Sleep(time.Duration(i) * 100 * time.Microsecond)
+		an.Stop()
+		ft.Reset()
+		test.That(t, sd.FillImage(3, still), test.ShouldBeNil)
+		time.Sleep(2 * time.Millisecond)
+		// the still image is written at most once (unless already shown)
+		written := ft.Written()
+		test.That(t, len(written), test.ShouldBeLessThanOrEqualTo, 1)
+		for _, w := range written {
+			test.That(t, w[8:8+len(want)], test.ShouldResemble, want)
+		}
+		sd.lock.Lock()
+		test.That(t, sd.shown[3], test.ShouldResemble, want)
+		sd.lock.Unlock()
+	}
+
+	// filling the key stops the animation
+	an, err := sd.PlayAnimation(3, frames, delays, true)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, sd.FillImage(3, still), test.ShouldBeNil)
+	waitDone(t, an)
+	ft.Reset()
+	time.Sleep(2 * time.Millisecond)
+	test.That(t, len(ft.Written()), test.ShouldEqual, 0)
+}
+
+func TestPlayAnimationAfterClose(t *testing.T) {
+	frames := []image.Image{solidImage(72, color.White), solidImage(72, black)}
+	delays := []time.Duration{time.Millisecond, time.Millisecond}
+
+	sd, _ := newFakeStreamDeck(t, Original2)
+	an, err := sd.PlayAnimation(1, frames, delays, true)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, sd.Close(), test.ShouldBeNil)
+	waitDone(t, an)
+	_, err = sd.PlayAnimation(1, frames, delays, true)
+	test.That(t, err, test.ShouldEqual, ErrDisconnected)
+
+	// no animation has been played before
+	sd, _ = newFakeStreamDeck(t, Original2)
+	test.That(t, sd.Close(), test.ShouldBeNil)
+	_, err = sd.PlayAnimation(1, frames, delays, true)
+	test.That(t, err, test.ShouldEqual, ErrDisconnected)
+	test.That(t, sd.animator, test.ShouldBeNil)
+}
+
+func TestDecodeGIFDisposal(t *testing.T) {
+	transparent := color.RGBA{}
+	red := color.RGBA{255, 0, 0, 255}
+	green := color.RGBA{0, 255, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	white := color.RGBA{255, 255, 255, 255}
+	palette := color.Palette{transparent, red, green, blue, white}
+
+	frame := func(x0, x1 int, c color.Color) *image.Paletted {
+		img := image.NewPaletted(image.Rect(x0, 0, x1, 1), palette)
+		for x := x0; x < x1; x++ {
+			img.Set(x, 0, c)
+		}
+		return img
+	}
+
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			frame(0, 4, green),
+			frame(0, 2, red),
+			frame(3, 4, blue),
+			frame(2, 3, white),
+		},
+		Delay:     []int{0, 5, 10, 20},
+		Disposal:  []byte{gif.DisposalNone, gif.DisposalBackground, gif.DisposalPrevious, gif.DisposalNone},
+		LoopCount: -1,
+		Config:    image.Config{ColorModel: palette, Width: 4, Height: 1},
+	}
+	buf := bytes.Buffer{}
+	test.That(t, gif.EncodeAll(&buf, g), test.ShouldBeNil)
+
+	frames, delays, plays, err := decodeGIF(&buf)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, plays, test.ShouldEqual, 1)
+	test.That(t, delays, test.ShouldResemble, []time.Duration{
+		100 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond, 200 * time.Millisecond})
+
+	want := [][]color.RGBA{
+		{green, green, green, green},
+		{red, red, green, green},
+		{transparent, transparent, green, blue},
+		{transparent, transparent, white, green},
+	}
+	test.That(t, len(frames), test.ShouldEqual, len(want))
+	for i, row := range want {
+		for x, c := range row {
+			test.That(t, frames[i].At(x, 0), test.ShouldResemble, c)
+		}
+	}
+}
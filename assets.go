@@ -34,6 +34,12 @@ func initHelp() error {
 	}
 	MonoMedium = f
 
+	if _, err := DefaultFontRegistry.Register("MonoRegular", MonoRegular); err != nil {
+		return err
+	}
+	if _, err := DefaultFontRegistry.Register("MonoMedium", MonoMedium); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -0,0 +1,315 @@
+package streamdeck
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+
+	"github.com/disintegration/gift"
+)
+
+// layoutBase is the key size (in pixel) which the sizes of layers refer
+// to. They are scaled to the actual key size, so that a key looks the same
+// on every model.
+const layoutBase = 72.0
+
+func layoutScale(rect image.Rectangle) float64 {
+	return float64(rect.Dx()) / layoutBase
+}
+
+// scaled converts a size on a 72 pixel key to rect, using def for zero.
+func scaled(v, def int, rect image.Rectangle) int {
+	if v == 0 {
+		v = def
+	}
+	return int(math.Round(float64(v) * layoutScale(rect)))
+}
+
+// Layer is drawn onto a key. TextButton and IconButton are layers as well.
+type Layer interface {
+	// Draw draws the layer over the content of rect, the area of the key.
+	Draw(dst draw.Image, rect image.Rectangle)
+}
+
+// ColorLayer fills the key with a color.
+type ColorLayer struct {
+	Color color.Color
+}
+
+// Draw implements Layer.
+func (l ColorLayer) Draw(dst draw.Image, rect image.Rectangle) {
+	if l.Color != nil {
+		draw.Draw(dst, rect, image.NewUniform(l.Color), image.Point{}, draw.Over)
+	}
+}
+
+// GradientLayer fills the key with a linear gradient. Angle (in degrees)
+// is the direction from From to To: 0 is left to right, 90 top to bottom.
+type GradientLayer struct {
+	From  color.Color
+	To    color.Color
+	Angle float64
+}
+
+// Draw implements Layer.
+func (l GradientLayer) Draw(dst draw.Image, rect image.Rectangle) {
+	from := color.NRGBAModel.Convert(orTransparent(l.From)).(color.NRGBA)
+	to := color.NRGBAModel.Convert(orTransparent(l.To)).(color.NRGBA)
+	sin, cos := math.Sincos(l.Angle * math.Pi / 180)
+
+	// project the corners on the direction to normalize the positions
+	w, h := float64(rect.Dx()), float64(rect.Dy())
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, p := range [][2]float64{{0, 0}, {w, 0}, {0, h}, {w, h}} {
+		d := p[0]*cos + p[1]*sin
+		lo, hi = math.Min(lo, d), math.Max(hi, d)
+	}
+
+	lerp := func(a, b uint8, t float64) uint8 {
+		return uint8(math.Round(float64(a) + (float64(b)-float64(a))*t))
+	}
+	img := image.NewNRGBA(rect)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			px, py := float64(x-rect.Min.X)+0.5, float64(y-rect.Min.Y)+0.5
+			t := (px*cos + py*sin - lo) / math.Max(hi-lo, 1)
+			img.SetNRGBA(x, y, color.NRGBA{
+				lerp(from.R, to.R, t), lerp(from.G, to.G, t),
+				lerp(from.B, to.B, t), lerp(from.A, to.A, t),
+			})
+		}
+	}
+	draw.Draw(dst, rect, img, rect.Min, draw.Over)
+}
+
+// ImageLayer draws an image fitted to the key. Background is used for the
+// borders of FitLetterbox and is transparent by default.
+type ImageLayer struct {
+	Image      image.Image
+	Fit        FitMode
+	Background color.Color
+}
+
+// Draw implements Layer.
+func (l ImageLayer) Draw(dst draw.Image, rect image.Rectangle) {
+	if l.Image == nil {
+		return
+	}
+	img := fitImage(l.Image, rect.Dx(), rect.Dy(), l.Fit, orTransparent(l.Background))
+	draw.Draw(dst, rect, img, image.Point{}, draw.Over)
+}
+
+// Corner is a corner of a key.
+type Corner int
+
+const (
+	TopRight Corner = iota
+	TopLeft
+	BottomRight
+	BottomLeft
+)
+
+// Badge is a notification badge showing a count in a corner of the key.
+// Sizes refer to a 72 pixel key.
+type Badge struct {
+	Count int
+	// Max is the largest count shown, larger counts are shown as "Max+".
+	// Defaults to 99.
+	Max int
+	// ShowZero draws the badge for a zero count, otherwise it is hidden.
+	ShowZero  bool
+	Corner    Corner
+	Color     color.Color // defaults to red
+	TextColor color.Color // defaults to white
+	// Size is the height of the badge, defaults to 22.
+	Size int
+	// Margin to the key border, defaults to 2.
+	Margin int
+}
+
+// Draw implements Layer.
+func (l Badge) Draw(dst draw.Image, rect image.Rectangle) {
+	if l.Count == 0 && !l.ShowZero {
+		return
+	}
+	maxCount := l.Max
+	if maxCount <= 0 {
+		maxCount = 99
+	}
+	text := strconv.Itoa(l.Count)
+	if l.Count > maxCount {
+		text = fmt.Sprintf("%d+", maxCount)
+	}
+	bg := l.Color
+	if bg == nil {
+		bg = color.RGBA{220, 30, 30, 255}
+	}
+
+	size := scaled(l.Size, 22, rect)
+	margin := scaled(l.Margin, 2, rect)
+	line := TextLine{Text: text, FontName: "MonoMedium", FontSize: float64(size) * 0.65, FontColor: l.TextColor}
+	textWidth, _ := measureLine(line)
+	// a circle for short counts, a pill for longer ones
+	width := max(size, textWidth+size/2)
+
+	var badge image.Rectangle
+	switch l.Corner {
+	case TopLeft:
+		badge = image.Rect(rect.Min.X+margin, rect.Min.Y+margin, rect.Min.X+margin+width, rect.Min.Y+margin+size)
+	case BottomRight:
+		badge = image.Rect(rect.Max.X-margin-width, rect.Max.Y-margin-size, rect.Max.X-margin, rect.Max.Y-margin)
+	case BottomLeft:
+		badge = image.Rect(rect.Min.X+margin, rect.Max.Y-margin-size, rect.Min.X+margin+width, rect.Max.Y-margin)
+	default:
+		badge = image.Rect(rect.Max.X-margin-width, rect.Min.Y+margin, rect.Max.X-margin, rect.Min.Y+margin+size)
+	}
+
+	draw.DrawMask(dst, badge, image.NewUniform(bg), image.Point{}, roundedRectMask(badge, size/2), badge.Min, draw.Over)
+	DrawText(dst, badge, []TextLine{line}, &TextLayout{HAlign: AlignCenter, VAlign: AlignMiddle})
+}
+
+// ProgressBar is a horizontal bar at the bottom of the key. Sizes refer to
+// a 72 pixel key.
+type ProgressBar struct {
+	// Value is the progress from 0 to 1.
+	Value      float64
+	Color      color.Color // defaults to white
+	TrackColor color.Color // defaults to translucent gray
+	// Height defaults to 6.
+	Height int
+	// Margin to the key border, defaults to 4.
+	Margin int
+}
+
+// Draw implements Layer.
+func (l ProgressBar) Draw(dst draw.Image, rect image.Rectangle) {
+	fg, track := l.Color, l.TrackColor
+	if fg == nil {
+		fg = color.White
+	}
+	if track == nil {
+		track = color.NRGBA{128, 128, 128, 128}
+	}
+	height := scaled(l.Height, 6, rect)
+	margin := scaled(l.Margin, 4, rect)
+
+	bar := image.Rect(rect.Min.X+margin, rect.Max.Y-margin-height, rect.Max.X-margin, rect.Max.Y-margin)
+	mask := roundedRectMask(bar, height/2)
+	draw.DrawMask(dst, bar, image.NewUniform(track), image.Point{}, mask, bar.Min, draw.Over)
+
+	value := math.Max(0, math.Min(1, l.Value))
+	filled := bar
+	filled.Max.X = bar.Min.X + int(math.Round(value*float64(bar.Dx())))
+	if filled.Empty() {
+		return
+	}
+	// round the filled part like the track, but cut it off at the value
+	draw.DrawMask(dst, filled, image.NewUniform(fg), image.Point{}, mask, filled.Min, draw.Over)
+}
+
+// BorderRing is a (rounded) ring along the border of the key, e.g. to
+// highlight it. Sizes refer to a 72 pixel key.
+type BorderRing struct {
+	Color color.Color // defaults to white
+	// Width defaults to 4.
+	Width int
+	// Radius of the corners.
+	Radius int
+}
+
+// Draw implements Layer.
+func (l BorderRing) Draw(dst draw.Image, rect image.Rectangle) {
+	c := l.Color
+	if c == nil {
+		c = color.White
+	}
+	width := scaled(l.Width, 4, rect)
+	radius := scaled(l.Radius, 0, rect)
+
+	// the ring is the outer rounded rectangle without the inner one
+	mask := roundedRectMask(rect, radius)
+	inner := rect.Inset(width)
+	if !inner.Empty() {
+		hole := roundedRectMask(inner, max(radius-width, 0))
+		for y := inner.Min.Y; y < inner.Max.Y; y++ {
+			for x := inner.Min.X; x < inner.Max.X; x++ {
+				a := int(mask.AlphaAt(x, y).A) - int(hole.AlphaAt(x, y).A)
+				mask.SetAlpha(x, y, color.Alpha{uint8(max(a, 0))})
+			}
+		}
+	}
+	draw.DrawMask(dst, rect, image.NewUniform(c), image.Point{}, mask, rect.Min, draw.Over)
+}
+
+func orTransparent(c color.Color) color.Color {
+	if c == nil {
+		return color.Transparent
+	}
+	return c
+}
+
+// canvasLayer is a layer with the filters applied to it.
+type canvasLayer struct {
+	layer   Layer
+	filters []gift.Filter
+}
+
+// KeyCanvas composes a key image from layers, which are drawn from the
+// bottom to the top. Each layer can be post-processed with gift filters,
+// e.g. gift.Grayscale(), gift.Brightness(-30) or gift.GaussianBlur(2),
+// which only affect this layer.
+type KeyCanvas struct {
+	size   int
+	layers []canvasLayer
+}
+
+// NewKeyCanvas returns an empty canvas for keys of size x size pixel.
+func NewKeyCanvas(size int) *KeyCanvas {
+	return &KeyCanvas{size: size}
+}
+
+// NewKeyCanvas returns an empty canvas with the key size of the Stream Deck.
+func (sd *StreamDeck) NewKeyCanvas() *KeyCanvas {
+	return NewKeyCanvas(sd.Config.ButtonSize)
+}
+
+// Size returns the width and height of the canvas in pixel.
+func (c *KeyCanvas) Size() int {
+	return c.size
+}
+
+// Add puts a layer on top of the canvas. It returns the canvas, so that
+// calls can be chained.
+func (c *KeyCanvas) Add(l Layer, filters ...gift.Filter) *KeyCanvas {
+	c.layers = append(c.layers, canvasLayer{layer: l, filters: filters})
+	return c
+}
+
+// Render draws all layers onto a new image. Uncovered areas are black.
+func (c *KeyCanvas) Render() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, c.size, c.size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+
+	for _, l := range c.layers {
+		if len(l.filters) == 0 {
+			l.layer.Draw(img, img.Bounds())
+			continue
+		}
+		// filters are applied to the layer alone before it is composed
+		layer := image.NewRGBA(img.Bounds())
+		l.layer.Draw(layer, layer.Bounds())
+		g := gift.New(l.filters...)
+		filtered := image.NewRGBA(g.Bounds(layer.Bounds()))
+		g.Draw(filtered, layer)
+		draw.Draw(img, img.Bounds(), filtered, filtered.Bounds().Min, draw.Over)
+	}
+	return img
+}
+
+// FillCanvas renders the canvas onto a key.
+func (sd *StreamDeck) FillCanvas(btnIndex int, c *KeyCanvas) error {
+	return sd.FillImage(btnIndex, c.Render())
+}
@@ -0,0 +1,115 @@
+package streamdeck
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/gift"
+	"go.viam.com/test"
+)
+
+func TestKeyCanvasLayers(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	white := color.RGBA{255, 255, 255, 255}
+
+	img := NewKeyCanvas(72).
+		Add(ColorLayer{Color: red}).
+		Add(BorderRing{Color: color.White, Width: 4}).
+		Render()
+	test.That(t, img.RGBAAt(1, 36), test.ShouldResemble, white)
+	test.That(t, img.RGBAAt(36, 70), test.ShouldResemble, white)
+	test.That(t, img.RGBAAt(36, 36), test.ShouldResemble, red)
+	test.That(t, img.RGBAAt(5, 36), test.ShouldResemble, red)
+
+	// uncovered areas are black, translucent layers are blended
+	img = NewKeyCanvas(72).Add(ColorLayer{Color: color.NRGBA{255, 255, 255, 128}}).Render()
+	test.That(t, img.RGBAAt(0, 0).R, test.ShouldBeBetween, 120, 136)
+	test.That(t, img.RGBAAt(0, 0).A, test.ShouldEqual, 255)
+}
+
+func TestGradientLayer(t *testing.T) {
+	img := NewKeyCanvas(72).Add(GradientLayer{From: color.Black, To: color.White, Angle: 90}).Render()
+	top, bottom := img.RGBAAt(10, 0), img.RGBAAt(10, 71)
+	test.That(t, top.R, test.ShouldBeLessThan, 5)
+	test.That(t, bottom.R, test.ShouldBeGreaterThan, 250)
+	// constant along the rows
+	test.That(t, img.RGBAAt(60, 36), test.ShouldResemble, img.RGBAAt(10, 36))
+}
+
+func TestImageLayerFit(t *testing.T) {
+	blue := color.RGBA{0, 0, 255, 255}
+	wide := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for i := range wide.Pix {
+		wide.Pix[i] = []byte{0, 0, 255, 255}[i%4]
+	}
+
+	img := NewKeyCanvas(72).
+		Add(ColorLayer{Color: color.White}).
+		Add(ImageLayer{Image: wide, Fit: FitLetterbox}).
+		Render()
+	// the letterbox borders are transparent
+	test.That(t, img.RGBAAt(36, 2), test.ShouldResemble, color.RGBA{255, 255, 255, 255})
+	test.That(t, img.RGBAAt(36, 36), test.ShouldResemble, blue)
+}
+
+func TestBadge(t *testing.T) {
+	black := color.RGBA{0, 0, 0, 255}
+	test.That(t, inkBounds(NewKeyCanvas(72).Add(Badge{}).Render(), black).Empty(), test.ShouldBeTrue)
+
+	one := inkBounds(NewKeyCanvas(72).Add(Badge{Count: 1}).Render(), black)
+	test.That(t, one, test.ShouldResemble, image.Rect(48, 2, 70, 24))
+
+	// larger counts are capped and shown in a wider pill
+	img := NewKeyCanvas(72).Add(Badge{Count: 1234, Corner: BottomLeft}).Render()
+	many := inkBounds(img, black)
+	test.That(t, many.Min.X, test.ShouldEqual, 2)
+	test.That(t, many.Max.Y, test.ShouldEqual, 70)
+	test.That(t, many.Dx(), test.ShouldBeGreaterThan, one.Dx())
+
+	// sizes scale with the key
+	large := inkBounds(NewKeyCanvas(144).Add(Badge{Count: 1}).Render(), black)
+	test.That(t, large.Dy(), test.ShouldEqual, 2*one.Dy())
+}
+
+func TestProgressBar(t *testing.T) {
+	white := color.RGBA{255, 255, 255, 255}
+	img := NewKeyCanvas(72).Add(ProgressBar{Value: 0.5, TrackColor: color.RGBA{0, 0, 255, 255}}).Render()
+	y := 72 - 4 - 3
+	test.That(t, img.RGBAAt(20, y), test.ShouldResemble, white)
+	test.That(t, img.RGBAAt(50, y), test.ShouldResemble, color.RGBA{0, 0, 255, 255})
+	test.That(t, img.RGBAAt(20, 30), test.ShouldResemble, color.RGBA{0, 0, 0, 255})
+}
+
+func TestLayerFilters(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	img := NewKeyCanvas(72).
+		Add(ColorLayer{Color: red}).
+		Add(BorderRing{Color: color.RGBA{0, 255, 0, 255}}, gift.Grayscale()).
+		Render()
+	// only the ring is affected
+	ring := img.RGBAAt(1, 36)
+	test.That(t, ring.R, test.ShouldEqual, ring.G)
+	test.That(t, img.RGBAAt(36, 36), test.ShouldResemble, red)
+
+	img = NewKeyCanvas(72).Add(TextButton{
+		Lines:  []TextLine{{Text: "X", FontSize: 30}},
+		Layout: &TextLayout{HAlign: AlignCenter, VAlign: AlignMiddle},
+	}, gift.GaussianBlur(3)).Render()
+	sharp := NewKeyCanvas(72).Add(TextButton{
+		Lines:  []TextLine{{Text: "X", FontSize: 30}},
+		Layout: &TextLayout{HAlign: AlignCenter, VAlign: AlignMiddle},
+	}).Render()
+	black := color.RGBA{0, 0, 0, 255}
+	test.That(t, inkBounds(img, black).Dx(), test.ShouldBeGreaterThan, inkBounds(sharp, black).Dx())
+}
+
+func TestFillCanvas(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Plus)
+
+	c := sd.NewKeyCanvas()
+	test.That(t, c.Size(), test.ShouldEqual, Plus.ButtonSize)
+	c.Add(ColorLayer{Color: color.White}).Add(IconButton{Icon: GlyphIcon{Rune: 'A'}, Tint: color.Black})
+	test.That(t, sd.FillCanvas(1, c), test.ShouldBeNil)
+	test.That(t, writtenKeys(ft), test.ShouldResemble, map[int]bool{1: true})
+}
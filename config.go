@@ -5,14 +5,24 @@ import (
 )
 
 type Config struct {
+	Name             string // Name is the human readable model name.
 	ProductID        uint16 // ProductID is the USB ProductID
 	NumButtonColumns int
 	NumButtonRows    int
 	Spacer           int // Spacer is the spacing distance (in pixel) of two buttons on the Stream Deck.
 	ButtonSize       int
 	ImageFormat      string
-	ImageRotate      bool
+	ImageRotate      bool // ImageRotate rotates the key images by 180°.
+	ImageTranspose   bool // ImageTranspose mirrors the key images along their main diagonal.
 	ConvertKey       bool
+	PagedBMP         bool // PagedBMP transfers BMP images in pages instead of the two fixed pages of the Original.
+	// ImageReportLength is the size (in bytes) of the output reports used
+	// to transfer key images. If zero, 8191 is used for the Original and
+	// 1024 for all other models.
+	ImageReportLength int
+	NumDials          int // NumDials is the number of rotary encoders (Stream Deck Plus).
+	TouchStripWidth   int // TouchStripWidth is the width (in pixel) of the LCD touch strip (Stream Deck Plus).
+	TouchStripHeight  int // TouchStripHeight is the height (in pixel) of the LCD touch strip (Stream Deck Plus).
 }
 
 func (c Config) NumButtons() int {
@@ -29,6 +39,82 @@ func (c *Config) PanelHeight() int {
 	return c.NumButtonRows*c.ButtonSize + c.Spacer*(c.NumButtonRows-1)
 }
 
+// legacyReports returns true for the first generation of Stream Decks
+// (Original, Mini) which use BMP images, input reports without a header
+// and a different brightness feature report.
+func (c *Config) legacyReports() bool {
+	return c.ImageFormat == "bmp"
+}
+
+// HasTouchStrip returns true if the device has an LCD touch strip.
+func (c *Config) HasTouchStrip() bool {
+	return c.TouchStripWidth > 0 && c.TouchStripHeight > 0
+}
+
+// featureReport describes a feature report which contains a zero
+// terminated string starting at offset.
+type featureReport struct {
+	id     byte
+	length int
+	offset int
+}
+
+// firmwareReport is the feature report holding the version of the
+// application firmware.
+func (c *Config) firmwareReport() featureReport {
+	if c.legacyReports() {
+		return featureReport{id: 0x04, length: 17, offset: 5}
+	}
+	return featureReport{id: 0x05, length: 32, offset: 6}
+}
+
+// ldFirmwareReport is the feature report holding the version of the
+// bootloader (LD) firmware. Only available on the second generation
+// of Stream Decks.
+func (c *Config) ldFirmwareReport() (featureReport, bool) {
+	if c.legacyReports() {
+		return featureReport{}, false
+	}
+	return featureReport{id: 0x04, length: 32, offset: 6}, true
+}
+
+// serialReport is the feature report holding the serial number of the device.
+func (c *Config) serialReport() featureReport {
+	if c.legacyReports() {
+		return featureReport{id: 0x03, length: 17, offset: 5}
+	}
+	return featureReport{id: 0x06, length: 32, offset: 2}
+}
+
+// resetReport returns the feature report which resets the device to
+// show the Elgato logo.
+func (c *Config) resetReport() []byte {
+	if c.legacyReports() {
+		buf := make([]byte, 17)
+		copy(buf, []byte{0x0B, 0x63})
+		return buf
+	}
+	buf := make([]byte, 32)
+	copy(buf, []byte{0x03, 0x02})
+	return buf
+}
+
+func (c *Config) imageReportLength() int {
+	if c.ImageReportLength > 0 {
+		return c.ImageReportLength
+	}
+	if c.ImageFormat == "bmp" && !c.PagedBMP {
+		return 8191
+	}
+	return 1024
+}
+
+// inputReportLength is the size of the buffer used for reading input
+// reports from the device.
+func (c *Config) inputReportLength() int {
+	return max(24, 4+c.NumButtons())
+}
+
 func (c *Config) fixKey(key int) int {
 	if c.ConvertKey {
 		keyCol := key % c.NumButtonColumns
@@ -39,46 +125,110 @@ func (c *Config) fixKey(key int) int {
 
 // Model 20GAA9901
 var Original = Config{
-	ProductID:        0x60,
-	NumButtonColumns: 5,
-	NumButtonRows:    3,
-	Spacer:           19,
-	ButtonSize:       72,
-	ImageFormat:      "bmp",
-	ConvertKey:       true,
+	Name:              "Stream Deck Original",
+	ProductID:         0x60,
+	NumButtonColumns:  5,
+	NumButtonRows:     3,
+	Spacer:            19,
+	ButtonSize:        72,
+	ImageFormat:       "bmp",
+	ImageRotate:       true,
+	ConvertKey:        true,
+	ImageReportLength: 8191,
 }
 
 // Model 20GAA9902
 var OriginalMk1 = Config{
-	ProductID:        0x6d,
-	NumButtonColumns: 5,
-	NumButtonRows:    3,
-	Spacer:           19,
-	ButtonSize:       72,
-	ImageFormat:      "jpg",
-	ImageRotate:      true,
+	Name:              "Stream Deck Original V2",
+	ProductID:         0x6d,
+	NumButtonColumns:  5,
+	NumButtonRows:     3,
+	Spacer:            19,
+	ButtonSize:        72,
+	ImageFormat:       "jpg",
+	ImageRotate:       true,
+	ImageReportLength: 1024,
 }
 
 var Original2 = Config{
-	ProductID:        0x80,
-	NumButtonColumns: 5,
-	NumButtonRows:    3,
-	Spacer:           19,
-	ButtonSize:       72,
-	ImageFormat:      "jpg",
-	ImageRotate:      true,
+	Name:              "Stream Deck MK.2",
+	ProductID:         0x80,
+	NumButtonColumns:  5,
+	NumButtonRows:     3,
+	Spacer:            19,
+	ButtonSize:        72,
+	ImageFormat:       "jpg",
+	ImageRotate:       true,
+	ImageReportLength: 1024,
 }
 
 var Plus = Config{
-	ProductID:        0x0084,
-	NumButtonColumns: 4,
-	NumButtonRows:    2,
-	Spacer:           19,
-	ButtonSize:       120,
-	ImageFormat:      "jpg",
+	Name:              "Stream Deck +",
+	ProductID:         0x0084,
+	NumButtonColumns:  4,
+	NumButtonRows:     2,
+	Spacer:            19,
+	ButtonSize:        120,
+	ImageFormat:       "jpg",
+	ImageReportLength: 1024,
+	NumDials:          4,
+	TouchStripWidth:   800,
+	TouchStripHeight:  100,
+}
+
+// Model 20GAI9901
+var Mini = Config{
+	Name:              "Stream Deck Mini",
+	ProductID:         0x63,
+	NumButtonColumns:  3,
+	NumButtonRows:     2,
+	Spacer:            19,
+	ButtonSize:        80,
+	ImageFormat:       "bmp",
+	ImageTranspose:    true,
+	PagedBMP:          true,
+	ImageReportLength: 1024,
+}
+
+var MiniMk2 = Config{
+	Name:              "Stream Deck Mini MK.2",
+	ProductID:         0x90,
+	NumButtonColumns:  3,
+	NumButtonRows:     2,
+	Spacer:            19,
+	ButtonSize:        80,
+	ImageFormat:       "bmp",
+	ImageTranspose:    true,
+	PagedBMP:          true,
+	ImageReportLength: 1024,
+}
+
+// Model 10GAT9901
+var XL = Config{
+	Name:              "Stream Deck XL",
+	ProductID:         0x6c,
+	NumButtonColumns:  8,
+	NumButtonRows:     4,
+	Spacer:            19,
+	ButtonSize:        96,
+	ImageFormat:       "jpg",
+	ImageRotate:       true,
+	ImageReportLength: 1024,
+}
+
+var XL2 = Config{
+	Name:              "Stream Deck XL rev.2",
+	ProductID:         0x8f,
+	NumButtonColumns:  8,
+	NumButtonRows:     4,
+	Spacer:            19,
+	ButtonSize:        96,
+	ImageFormat:       "jpg",
+	ImageRotate:       true,
+	ImageReportLength: 1024,
 }
 
-var AllConfigs = []Config{Original, OriginalMk1, Original2, Plus}
+var AllConfigs = []Config{Original, OriginalMk1, Original2, Plus, Mini, MiniMk2, XL, XL2}
 
 func FindConnectedConfig() (Config, bool) {
 	for _, c := range AllConfigs {
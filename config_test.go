@@ -1,6 +1,7 @@
 package streamdeck
 
 import (
+	"encoding/binary"
 	"go.viam.com/test"
 	"image"
 	"image/color"
@@ -27,3 +28,28 @@ func TestRGB(t *testing.T) {
 	test.That(t, len(data), test.ShouldEqual, 54+(72*72*3))
 
 }
+
+func TestBMPHeader(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, Mini.ButtonSize, Mini.ButtonSize))
+
+	data, err := encodeBMP(&Mini, img)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(data), test.ShouldEqual, 54+(80*80*3))
+	test.That(t, data[:2], test.ShouldResemble, []byte("BM"))
+	test.That(t, binary.LittleEndian.Uint32(data[2:]), test.ShouldEqual, 54+(80*80*3))
+	test.That(t, binary.LittleEndian.Uint32(data[18:]), test.ShouldEqual, 80)
+	test.That(t, binary.LittleEndian.Uint32(data[22:]), test.ShouldEqual, 80)
+}
+
+func TestOrientImage(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	img := image.NewRGBA(image.Rect(0, 0, Mini.ButtonSize, Mini.ButtonSize))
+	img.Set(10, 0, red)
+
+	test.That(t, orientImage(&Mini, img).At(0, 10), test.ShouldResemble, red)
+	test.That(t, orientImage(&Plus, img), test.ShouldEqual, img)
+
+	img = image.NewRGBA(image.Rect(0, 0, Original2.ButtonSize, Original2.ButtonSize))
+	img.Set(10, 0, red)
+	test.That(t, orientImage(&Original2, img).At(72-1-10, 72-1), test.ShouldResemble, red)
+}
@@ -0,0 +1,124 @@
+package streamdeck
+
+import (
+	"fmt"
+)
+
+// DialMode selects how the position of a dial (State.DialPos) is updated
+// when the dial is turned.
+type DialMode int
+
+const (
+	// DialClamp limits the position to the range Min..Max.
+	DialClamp DialMode = iota
+	// DialWrap wraps the position around from Max to Min and vice versa.
+	DialWrap
+	// DialUnbounded accumulates the ticks without any limits.
+	DialUnbounded
+)
+
+func (m DialMode) String() string {
+	switch m {
+	case DialClamp:
+		return "clamp"
+	case DialWrap:
+		return "wrap"
+	case DialUnbounded:
+		return "unbounded"
+	default:
+		return "unknown"
+	}
+}
+
+// DialConfig configures the position counter of a dial. Min and Max are
+// inclusive and ignored in DialUnbounded mode. Initial is the position
+// the counter starts with.
+type DialConfig struct {
+	Mode    DialMode
+	Min     int
+	Max     int
+	Initial int
+}
+
+// DefaultDialConfig is used for all dials which have not been configured.
+// The position is clamped to 0..DialMax and starts in the middle.
+var DefaultDialConfig = DialConfig{
+	Mode:    DialClamp,
+	Min:     0,
+	Max:     DialMax,
+	Initial: 50,
+}
+
+func (dc DialConfig) validate() error {
+	if dc.Mode == DialUnbounded {
+		return nil
+	}
+	if dc.Min > dc.Max {
+		return fmt.Errorf("dial min %d is larger than max %d", dc.Min, dc.Max)
+	}
+	if dc.Initial < dc.Min || dc.Initial > dc.Max {
+		return fmt.Errorf("initial dial position %d is out of range %d..%d", dc.Initial, dc.Min, dc.Max)
+	}
+	return nil
+}
+
+// apply returns the new position after turning the dial by delta ticks.
+func (dc DialConfig) apply(pos, delta int) int {
+	switch dc.Mode {
+	case DialWrap:
+		span := dc.Max - dc.Min + 1
+		offset := (pos - dc.Min + delta) % span
+		if offset < 0 {
+			offset += span
+		}
+		return dc.Min + offset
+	case DialUnbounded:
+		return pos + delta
+	default:
+		return min(dc.Max, max(dc.Min, pos+delta))
+	}
+}
+
+// ConfigureDial sets the counter configuration of a dial and resets its
+// position to dc.Initial.
+func (s *State) ConfigureDial(dialIndex int, dc DialConfig) error {
+	if dialIndex < 0 {
+		return fmt.Errorf("invalid dial index")
+	}
+	if err := dc.validate(); err != nil {
+		return err
+	}
+	s.growDials(dialIndex + 1)
+	s.Dials[dialIndex] = dc
+	s.DialPos[dialIndex] = dc.Initial
+	return nil
+}
+
+// growDials makes sure that State.Dials and State.DialPos hold at least n entries.
+func (s *State) growDials(n int) {
+	for len(s.Dials) < n {
+		s.Dials = append(s.Dials, DefaultDialConfig)
+	}
+	for len(s.DialPos) < n {
+		s.DialPos = append(s.DialPos, s.Dials[len(s.DialPos)].Initial)
+	}
+}
+
+// ConfigureDial sets the counter configuration of a dial. The position
+// of the dial is reset to dc.Initial.
+func (sd *StreamDeck) ConfigureDial(dialIndex int, dc DialConfig) error {
+	if dialIndex < 0 || (sd.Config.NumDials > 0 && dialIndex >= sd.Config.NumDials) {
+		return fmt.Errorf("invalid dial index")
+	}
+	if err := dc.validate(); err != nil {
+		return err
+	}
+
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	if sd.pendingDials == nil {
+		sd.pendingDials = map[int]DialConfig{}
+	}
+	sd.pendingDials[dialIndex] = dc
+	return nil
+}
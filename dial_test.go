@@ -0,0 +1,66 @@
+package streamdeck
+
+import (
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestDialDelta(t *testing.T) {
+	s := State{}
+
+	// dial 0 turned by +2, dial 2 turned by -3
+	myEvent, err := s.Update(&Plus, []byte{1, 3, 5, 0, 1, 2, 0, 253, 0})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(myEvent), test.ShouldEqual, 2)
+	test.That(t, myEvent[0], test.ShouldResemble, Event{Kind: EventDialTurn, Which: 0, Delta: 2})
+	test.That(t, myEvent[1], test.ShouldResemble, Event{Kind: EventDialTurn, Which: 2, Delta: -3})
+	test.That(t, s.DialPos, test.ShouldResemble, []int{52, 50, 47, 50})
+}
+
+func TestDialModes(t *testing.T) {
+	s := State{}
+
+	err := s.ConfigureDial(0, DialConfig{Mode: DialWrap, Min: 0, Max: 9, Initial: 8})
+	test.That(t, err, test.ShouldBeNil)
+	err = s.ConfigureDial(1, DialConfig{Mode: DialUnbounded, Initial: -1000})
+	test.That(t, err, test.ShouldBeNil)
+	err = s.ConfigureDial(2, DialConfig{Mode: DialClamp, Min: -5, Max: 5, Initial: 0})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, s.DialPos, test.ShouldResemble, []int{8, -1000, 0})
+
+	_, err = s.Update(&Plus, []byte{1, 3, 5, 0, 1, 3, 0x80, 10, 1})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, s.DialPos, test.ShouldResemble, []int{1, -1128, 5, 51})
+
+	_, err = s.Update(&Plus, []byte{1, 3, 5, 0, 1, 254, 0, 236, 0})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, s.DialPos, test.ShouldResemble, []int{9, -1128, -5, 51})
+
+	test.That(t, s.ConfigureDial(0, DialConfig{Mode: DialClamp, Min: 5, Max: 1}), test.ShouldNotBeNil)
+	test.That(t, s.ConfigureDial(0, DialConfig{Mode: DialWrap, Min: 0, Max: 1, Initial: 2}), test.ShouldNotBeNil)
+	test.That(t, s.ConfigureDial(-1, DefaultDialConfig), test.ShouldNotBeNil)
+}
+
+func TestStreamDeckConfigureDial(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Plus)
+
+	err := sd.ConfigureDial(1, DialConfig{Mode: DialUnbounded, Initial: 1000})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, sd.ConfigureDial(4, DefaultDialConfig), test.ShouldNotBeNil)
+
+	type result struct {
+		s State
+		e Event
+	}
+	results := make(chan result, 1)
+	sd.SetBtnEventCb(func(s State, e Event) {
+		results <- result{s, e}
+	})
+
+	ft.Inject([]byte{1, 3, 5, 0, 1, 0, 3, 0, 0})
+
+	r := <-results
+	test.That(t, r.e, test.ShouldResemble, Event{Kind: EventDialTurn, Which: 1, Delta: 3})
+	test.That(t, r.s.DialPos[1], test.ShouldEqual, 1003)
+}
@@ -0,0 +1,90 @@
+package streamdeck
+
+import (
+	"fmt"
+
+	"github.com/bearsh/hid"
+)
+
+// Descriptor describes a Stream Deck which is connected to this computer.
+type Descriptor struct {
+	Model    string // Model is the name of the Config, e.g. "Stream Deck XL".
+	Config   Config
+	Serial   string
+	Path     string // Path is the platform specific USB device path.
+	Firmware string // Firmware is empty if the device could not be opened.
+}
+
+func (d Descriptor) String() string {
+	return fmt.Sprintf("%s (serial: %s, firmware: %s, path: %s)", d.Model, d.Serial, d.Firmware, d.Path)
+}
+
+// Enumerate returns a Descriptor for every connected Stream Deck of all
+// known models (AllConfigs). Each device is briefly opened to read its
+// firmware version.
+func Enumerate() []Descriptor {
+	return enumerate(AllConfigs, func(productID uint16) []hid.DeviceInfo {
+		return hid.Enumerate(VendorID, productID)
+	}, openHIDPath)
+}
+
+// Open opens the Stream Deck described by d.
+func Open(d Descriptor) (*StreamDeck, error) {
+	t, err := openHIDPath(d.Path)
+	if err != nil {
+		return nil, err
+	}
+	c := d.Config
+	return NewStreamDeckWithTransport(&c, t)
+}
+
+// listConnected is like Enumerate, but doesn't open the devices, so it
+// can be used while some of them are in use. Firmware is always empty.
+func listConnected() []Descriptor {
+	return listDevices(AllConfigs, func(productID uint16) []hid.DeviceInfo {
+		return hid.Enumerate(VendorID, productID)
+	})
+}
+
+func listDevices(configs []Config, list func(productID uint16) []hid.DeviceInfo) []Descriptor {
+	descriptors := []Descriptor{}
+	for _, c := range configs {
+		for _, info := range list(c.ProductID) {
+			descriptors = append(descriptors, Descriptor{
+				Model:  c.Name,
+				Config: c,
+				Serial: info.Serial,
+				Path:   info.Path,
+			})
+		}
+	}
+	return descriptors
+}
+
+func enumerate(configs []Config, list func(productID uint16) []hid.DeviceInfo,
+	open func(path string) (Transport, error)) []Descriptor {
+
+	descriptors := listDevices(configs, list)
+	for i, d := range descriptors {
+		t, err := open(d.Path)
+		if err != nil {
+			debug("unable to open %s: %v", d.Path, err)
+			continue
+		}
+		fw, err := readFirmwareVersion(&d.Config, t)
+		if err != nil {
+			debug("unable to read firmware version of %s: %v", d.Path, err)
+		}
+		descriptors[i].Firmware = fw
+		t.Close()
+	}
+	return descriptors
+}
+
+func openHIDPath(path string) (Transport, error) {
+	device, err := hid.OpenByPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return newHIDTransport(device), nil
+}
@@ -0,0 +1,48 @@
+package streamdeck
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bearsh/hid"
+	"go.viam.com/test"
+)
+
+func TestEnumerate(t *testing.T) {
+	connected := map[uint16][]hid.DeviceInfo{
+		Plus.ProductID: {{Path: "/dev/hidraw1", Serial: "PLUS0001"}},
+		XL.ProductID:   {{Path: "/dev/hidraw2", Serial: "XL000001"}},
+		Mini.ProductID: {{Path: "/dev/hidraw3", Serial: "MINI0001"}},
+	}
+	list := func(productID uint16) []hid.DeviceInfo {
+		return connected[productID]
+	}
+
+	open := func(path string) (Transport, error) {
+		ft := NewFakeTransport("")
+		switch path {
+		case "/dev/hidraw1":
+			ft.SetFeatureReport(append([]byte{0x05, 0x0C, 0xFE, 0x0, 0x0, 0x0}, "1.02.006\x00\x00"...))
+		case "/dev/hidraw2":
+			return nil, errors.New("busy")
+		case "/dev/hidraw3":
+			ft.SetFeatureReport(append([]byte{0x04, 0x55, 0xAA, 0xD4, 0x04}, "2.01.001"...))
+		}
+		return ft, nil
+	}
+
+	descriptors := enumerate(AllConfigs, list, open)
+	test.That(t, len(descriptors), test.ShouldEqual, 3)
+
+	test.That(t, descriptors[0].Model, test.ShouldEqual, "Stream Deck +")
+	test.That(t, descriptors[0].Serial, test.ShouldEqual, "PLUS0001")
+	test.That(t, descriptors[0].Firmware, test.ShouldEqual, "1.02.006")
+	test.That(t, descriptors[0].Config.ProductID, test.ShouldEqual, Plus.ProductID)
+
+	test.That(t, descriptors[1].Model, test.ShouldEqual, "Stream Deck Mini")
+	test.That(t, descriptors[1].Firmware, test.ShouldEqual, "2.01.001")
+
+	test.That(t, descriptors[2].Model, test.ShouldEqual, "Stream Deck XL")
+	test.That(t, descriptors[2].Path, test.ShouldEqual, "/dev/hidraw2")
+	test.That(t, descriptors[2].Firmware, test.ShouldEqual, "")
+}
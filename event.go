@@ -1,7 +1,9 @@
 package streamdeck
 
 import (
+	"encoding/binary"
 	"fmt"
+	"image"
 )
 
 const DialMax = 100
@@ -15,6 +17,22 @@ const (
 	EventDialPressed
 	EventDialReleased
 	EventDialTurn
+	EventTouchShort
+	EventTouchLong
+	EventTouchSwipe
+	EventKeyTap
+	EventKeyLongPress
+	EventKeyDoublePress
+	EventKeyRepeat
+	EventDialTap
+	EventDialLongPress
+	EventDialDoublePress
+	EventDialRepeat
+	EventDisconnected
+	EventReconnected
+	EventReconnectFailed
+	EventDeviceAdded
+	EventDeviceRemoved
 )
 
 func (ev EventKind) String() string {
@@ -29,24 +47,71 @@ func (ev EventKind) String() string {
 		return "dial-released"
 	case EventDialTurn:
 		return "dial-turn"
+	case EventTouchShort:
+		return "touch-short"
+	case EventTouchLong:
+		return "touch-long"
+	case EventTouchSwipe:
+		return "touch-swipe"
+	case EventKeyTap:
+		return "key-tap"
+	case EventKeyLongPress:
+		return "key-long-press"
+	case EventKeyDoublePress:
+		return "key-double-press"
+	case EventKeyRepeat:
+		return "key-repeat"
+	case EventDialTap:
+		return "dial-tap"
+	case EventDialLongPress:
+		return "dial-long-press"
+	case EventDialDoublePress:
+		return "dial-double-press"
+	case EventDialRepeat:
+		return "dial-repeat"
+	case EventDisconnected:
+		return "disconnected"
+	case EventReconnected:
+		return "reconnected"
+	case EventReconnectFailed:
+		return "reconnect-failed"
+	case EventDeviceAdded:
+		return "device-added"
+	case EventDeviceRemoved:
+		return "device-removed"
 	default:
 		return "unknown"
 	}
 }
 
+// Event describes a change of the Stream Deck's input state. For touch
+// events, Which is the index of the touch strip segment (dial) where the
+// touch started.
 type Event struct {
-	Kind  EventKind
-	Which int
+	Kind   EventKind
+	Which  int
+	Delta  int         // Delta is the signed number of ticks of a dial turn.
+	Pos    image.Point // Pos is the position of a touch or the start of a swipe.
+	EndPos image.Point // EndPos is the end position of a swipe.
 }
 
 func (e Event) String() string {
+	switch e.Kind {
+	case EventTouchShort, EventTouchLong:
+		return fmt.Sprintf("%s:%d@%d,%d", e.Kind.String(), e.Which, e.Pos.X, e.Pos.Y)
+	case EventTouchSwipe:
+		return fmt.Sprintf("%s:%d@%d,%d->%d,%d", e.Kind.String(), e.Which,
+			e.Pos.X, e.Pos.Y, e.EndPos.X, e.EndPos.Y)
+	}
 	return fmt.Sprintf("%s:%d", e.Kind.String(), e.Which)
 }
 
 type State struct {
 	Keys     []bool
 	DialPush []bool
-	DialPos  []int // 0 -> 100
+	DialPos  []int        // DialPos is the position of each dial, see DialConfig.
+	Dials    []DialConfig // Dials holds the counter configuration of each dial.
+	TouchPos image.Point  // TouchPos is the position of the last touch (end of a swipe).
 }
 
 func (s *State) Update(c *Config, b []byte) ([]Event, error) {
@@ -59,14 +124,38 @@ func (s *State) Update(c *Config, b []byte) ([]Event, error) {
 		return s.updateKeyPressOriginal(b[1:])
 	}
 
+	// the first generation devices (Original, Mini) send the key states
+	// right after the report ID
+	if c != nil && c.legacyReports() {
+		if len(b) < 1+c.NumButtons() {
+			return nil, fmt.Errorf("wrong amount of data for a %d key report %d", c.NumButtons(), len(b))
+		}
+		return s.updateKeyPress(b[1 : 1+c.NumButtons()])
+	}
+
 	switch b[1] {
 	case 0:
+		if c != nil {
+			if len(b) < 4+c.NumButtons() {
+				return nil, fmt.Errorf("wrong amount of data for a %d key report %d", c.NumButtons(), len(b))
+			}
+			return s.updateKeyPress(b[4 : 4+c.NumButtons()])
+		}
 		return s.updateKeyPress(b[4:])
 	case 3:
+		data := b[5:]
+		if c != nil && c.NumDials > 0 {
+			if len(data) < c.NumDials {
+				return nil, fmt.Errorf("wrong amount of data for %d dials %d", c.NumDials, len(data))
+			}
+			data = data[:c.NumDials]
+		}
 		if b[4] == 0 {
-			return s.updateDialPush(b[5:])
+			return s.updateDialPush(data)
 		}
-		return s.updateDialTurn(b[5:])
+		return s.updateDialTurn(data)
+	case 2:
+		return s.updateTouch(c, b)
 	default:
 		return nil, fmt.Errorf("unknown event type %d", b[1])
 	}
@@ -111,9 +200,9 @@ func (s *State) updateKeyPressOriginal(data []byte) ([]Event, error) {
 	changedKeys, s.Keys = applyBools(s.Keys, nd)
 	for _, changedKey := range changedKeys {
 		if s.Keys[changedKey] {
-			updateEvents = append(updateEvents, Event{EventKeyPressed, changedKey})
+			updateEvents = append(updateEvents, Event{Kind: EventKeyPressed, Which: changedKey})
 		} else {
-			updateEvents = append(updateEvents, Event{EventKeyReleased, changedKey})
+			updateEvents = append(updateEvents, Event{Kind: EventKeyReleased, Which: changedKey})
 		}
 	}
 	return updateEvents, nil
@@ -126,9 +215,9 @@ func (s *State) updateKeyPress(data []byte) ([]Event, error) {
 	changedKeys, s.Keys = applyBools(s.Keys, data)
 	for _, changedKey := range changedKeys {
 		if s.Keys[changedKey] {
-			updateEvents = append(updateEvents, Event{EventKeyPressed, changedKey})
+			updateEvents = append(updateEvents, Event{Kind: EventKeyPressed, Which: changedKey})
 		} else {
-			updateEvents = append(updateEvents, Event{EventKeyReleased, changedKey})
+			updateEvents = append(updateEvents, Event{Kind: EventKeyReleased, Which: changedKey})
 		}
 	}
 	return updateEvents, nil
@@ -141,46 +230,64 @@ func (s *State) updateDialPush(data []byte) ([]Event, error) {
 	updateEvents := []Event{}
 	for _, changedKey := range changedDialPushs {
 		if s.DialPush[changedKey] {
-			updateEvents = append(updateEvents, Event{EventDialPressed, changedKey})
+			updateEvents = append(updateEvents, Event{Kind: EventDialPressed, Which: changedKey})
 		} else {
-			updateEvents = append(updateEvents, Event{EventDialReleased, changedKey})
+			updateEvents = append(updateEvents, Event{Kind: EventDialReleased, Which: changedKey})
 		}
 	}
 	return updateEvents, nil
 }
 
-func (s *State) updateDialTurn(data []byte) ([]Event, error) {
-	var changedDialTurns int
-	updateEvents := []Event{}
-	changedDialTurns, s.DialPos = applyDelta(s.DialPos, data)
-
-	if changedDialTurns >= 0 {
-		updateEvents = append(updateEvents, Event{EventDialTurn, changedDialTurns})
-		return updateEvents, nil
+func (s *State) updateTouch(c *Config, b []byte) ([]Event, error) {
+	if len(b) < 14 {
+		return nil, fmt.Errorf("wrong amount of data for updateTouch %d", len(b))
 	}
-	return nil, nil
-}
 
-func applyDelta(in []int, data []byte) (int, []int) {
-	changed := -1
+	ev := Event{
+		Pos: image.Point{
+			X: int(binary.LittleEndian.Uint16(b[6:])),
+			Y: int(binary.LittleEndian.Uint16(b[8:])),
+		},
+	}
 
-	for i, d := range data {
-		if d != 0 {
-			changed = i
-		}
-		if len(in) <= i {
-			in = append(in, 50)
+	switch b[4] {
+	case 1:
+		ev.Kind = EventTouchShort
+	case 2:
+		ev.Kind = EventTouchLong
+	case 3:
+		ev.Kind = EventTouchSwipe
+		ev.EndPos = image.Point{
+			X: int(binary.LittleEndian.Uint16(b[10:])),
+			Y: int(binary.LittleEndian.Uint16(b[12:])),
 		}
+	default:
+		return nil, fmt.Errorf("unknown touch event type %d", b[4])
+	}
 
-		output := in[i]
+	if c != nil && c.NumDials > 0 && c.TouchStripWidth > 0 {
+		ev.Which = min(c.NumDials-1, ev.Pos.X/(c.TouchStripWidth/c.NumDials))
+	}
 
-		if d < 0x80 {
-			output = min(DialMax, output+int(d))
-		} else {
-			output = max(0, output-(256-int(d)))
-		}
+	s.TouchPos = ev.Pos
+	if ev.Kind == EventTouchSwipe {
+		s.TouchPos = ev.EndPos
+	}
+
+	return []Event{ev}, nil
+}
+
+func (s *State) updateDialTurn(data []byte) ([]Event, error) {
+	var updateEvents []Event
+	s.growDials(len(data))
 
-		in[i] = output
+	for i, d := range data {
+		delta := int(int8(d))
+		if delta == 0 {
+			continue
+		}
+		s.DialPos[i] = s.Dials[i].apply(s.DialPos[i], delta)
+		updateEvents = append(updateEvents, Event{Kind: EventDialTurn, Which: i, Delta: delta})
 	}
-	return changed, in
+	return updateEvents, nil
 }
@@ -1,6 +1,7 @@
 package streamdeck
 
 import (
+	"image"
 	"testing"
 
 	"go.viam.com/test"
@@ -70,7 +71,7 @@ func TestState(t *testing.T) {
 }
 
 func TestEventString(t *testing.T) {
-	test.That(t, Event{EventDialPressed, 5}.String(), test.ShouldEqual, "dial-pressed:5")
+	test.That(t, Event{Kind: EventDialPressed, Which: 5}.String(), test.ShouldEqual, "dial-pressed:5")
 }
 
 func TestStateOriginal(t *testing.T) {
@@ -98,6 +99,10 @@ func TestStateOriginal(t *testing.T) {
 	test.That(t, err, test.ShouldBeNil)
 	test.That(t, myEvent[0].String(), test.ShouldEqual, "key-pressed:10")
 
+	myEvent, err = s.Update(&Original, []byte{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, myEvent[0].String(), test.ShouldEqual, "key-released:10")
+
 	// button 14
 	myEvent, err = s.Update(&Original, []byte{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0})
 	t.Logf("myEvent 3: %v", myEvent)
@@ -105,3 +110,80 @@ func TestStateOriginal(t *testing.T) {
 	test.That(t, myEvent[0].String(), test.ShouldEqual, "key-pressed:14")
 
 }
+
+func TestStateMini(t *testing.T) {
+	s := State{}
+
+	// key 2 pressed (captured from a Stream Deck Mini)
+	myEvent, err := s.Update(&Mini, []byte{1, 0, 0, 1, 0, 0, 0})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(s.Keys), test.ShouldEqual, 6)
+	test.That(t, s.Keys[2], test.ShouldBeTrue)
+	test.That(t, myEvent[0].String(), test.ShouldEqual, "key-pressed:2")
+
+	// key 2 released, key 5 pressed
+	myEvent, err = s.Update(&MiniMk2, []byte{1, 0, 0, 0, 0, 0, 1})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(myEvent), test.ShouldEqual, 2)
+	test.That(t, myEvent[0].String(), test.ShouldEqual, "key-released:2")
+	test.That(t, myEvent[1].String(), test.ShouldEqual, "key-pressed:5")
+
+	_, err = s.Update(&Mini, []byte{1, 0, 0})
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestStateXL(t *testing.T) {
+	s := State{}
+
+	// key 31 (bottom right) pressed (captured from a Stream Deck XL)
+	report := make([]byte, 4+32)
+	copy(report, []byte{1, 0, 32, 0})
+	report[4+31] = 1
+	myEvent, err := s.Update(&XL, report)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(s.Keys), test.ShouldEqual, 32)
+	test.That(t, s.Keys[31], test.ShouldBeTrue)
+	test.That(t, myEvent[0].String(), test.ShouldEqual, "key-pressed:31")
+
+	// key 8 (second row, first column) pressed
+	report[4+8] = 1
+	myEvent, err = s.Update(&XL2, report)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(myEvent), test.ShouldEqual, 1)
+	test.That(t, myEvent[0].String(), test.ShouldEqual, "key-pressed:8")
+
+	_, err = s.Update(&XL, report[:20])
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestStateTouch(t *testing.T) {
+	s := State{}
+
+	// short touch at 250,40 (captured from a Stream Deck Plus)
+	myEvent, err := s.Update(&Plus, []byte{1, 2, 14, 0, 1, 0, 250, 0, 40, 0, 0, 0, 0, 0})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, myEvent[0].Kind, test.ShouldEqual, EventTouchShort)
+	test.That(t, myEvent[0].Which, test.ShouldEqual, 1)
+	test.That(t, myEvent[0].Pos, test.ShouldResemble, image.Point{250, 40})
+	test.That(t, myEvent[0].String(), test.ShouldEqual, "touch-short:1@250,40")
+	test.That(t, s.TouchPos, test.ShouldResemble, image.Point{250, 40})
+
+	// long touch at 790,10
+	myEvent, err = s.Update(&Plus, []byte{1, 2, 14, 0, 2, 0, 0x16, 3, 10, 0, 0, 0, 0, 0})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, myEvent[0].String(), test.ShouldEqual, "touch-long:3@790,10")
+
+	// swipe from 20,50 to 420,60
+	myEvent, err = s.Update(&Plus, []byte{1, 2, 14, 0, 3, 0, 20, 0, 50, 0, 0xA4, 1, 60, 0})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, myEvent[0].Kind, test.ShouldEqual, EventTouchSwipe)
+	test.That(t, myEvent[0].EndPos, test.ShouldResemble, image.Point{420, 60})
+	test.That(t, myEvent[0].String(), test.ShouldEqual, "touch-swipe:0@20,50->420,60")
+	test.That(t, s.TouchPos, test.ShouldResemble, image.Point{420, 60})
+
+	_, err = s.Update(&Plus, []byte{1, 2, 14, 0, 9, 0, 20, 0, 50, 0, 0, 0, 0, 0})
+	test.That(t, err, test.ShouldNotBeNil)
+
+	_, err = s.Update(&Plus, []byte{1, 2, 14, 0, 1})
+	test.That(t, err, test.ShouldNotBeNil)
+}
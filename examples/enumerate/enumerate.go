@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/dh1tw/streamdeck"
+)
+
+func main() {
+	devices := streamdeck.Enumerate()
+
+	fmt.Printf("Found %d Elgato Stream Deck(s):\n", len(devices))
+	for _, d := range devices {
+		fmt.Printf("\tModel:               %s\n", d.Model)
+		fmt.Printf("\tSerialNumber:        %s\n", d.Serial)
+		fmt.Printf("\tFirmware:            %s\n", d.Firmware)
+		fmt.Printf("\tPath:                %s\n", d.Path)
+	}
+}
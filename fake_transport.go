@@ -0,0 +1,160 @@
+package streamdeck
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrTransportClosed is returned by the FakeTransport once it has been closed.
+var ErrTransportClosed = errors.New("transport closed")
+
+// FakeTransport is an in-memory Transport which can be used to exercise
+// a StreamDeck without real hardware. It records every report written to it
+// and lets the caller inject input reports which are then returned by Read.
+type FakeTransport struct {
+	lock           sync.Mutex
+	serial         string
+	written        [][]byte
+	sentFeatures   [][]byte
+	featureReports map[byte][]byte
+	input          chan []byte
+	closed         chan struct{}
+	closeOnce      sync.Once
+}
+
+// NewFakeTransport returns a FakeTransport which reports the given serial number.
+func NewFakeTransport(serial string) *FakeTransport {
+	return &FakeTransport{
+		serial:         serial,
+		featureReports: map[byte][]byte{},
+		input:          make(chan []byte, 64),
+		closed:         make(chan struct{}),
+	}
+}
+
+// Inject queues an input report which will be returned by the next call to Read.
+func (f *FakeTransport) Inject(report []byte) {
+	r := make([]byte, len(report))
+	copy(r, report)
+	select {
+	case f.input <- r:
+	case <-f.closed:
+	}
+}
+
+// Written returns a copy of all output reports written so far.
+func (f *FakeTransport) Written() [][]byte {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return copyReports(f.written)
+}
+
+// SentFeatureReports returns a copy of all feature reports sent so far.
+func (f *FakeTransport) SentFeatureReports() [][]byte {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return copyReports(f.sentFeatures)
+}
+
+// Reset discards all recorded output and feature reports.
+func (f *FakeTransport) Reset() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.written = nil
+	f.sentFeatures = nil
+}
+
+// SetFeatureReport sets the response returned by GetFeatureReport for the
+// report ID in report[0].
+func (f *FakeTransport) SetFeatureReport(report []byte) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	r := make([]byte, len(report))
+	copy(r, report)
+	f.featureReports[report[0]] = r
+}
+
+// Read blocks until an input report has been injected or the transport is closed.
+func (f *FakeTransport) Read(b []byte) (int, error) {
+	select {
+	case r := <-f.input:
+		return copy(b, r), nil
+	case <-f.closed:
+		return 0, ErrTransportClosed
+	}
+}
+
+// Write records the output report.
+func (f *FakeTransport) Write(b []byte) (int, error) {
+	if f.isClosed() {
+		return 0, ErrTransportClosed
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	r := make([]byte, len(b))
+	copy(r, b)
+	f.written = append(f.written, r)
+	return len(b), nil
+}
+
+// SendFeatureReport records the feature report.
+func (f *FakeTransport) SendFeatureReport(b []byte) (int, error) {
+	if f.isClosed() {
+		return 0, ErrTransportClosed
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	r := make([]byte, len(b))
+	copy(r, b)
+	f.sentFeatures = append(f.sentFeatures, r)
+	return len(b), nil
+}
+
+// GetFeatureReport returns the response previously registered with
+// SetFeatureReport for the report ID in b[0].
+func (f *FakeTransport) GetFeatureReport(b []byte) (int, error) {
+	if f.isClosed() {
+		return 0, ErrTransportClosed
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	r, ok := f.featureReports[b[0]]
+	if !ok {
+		return 0, fmt.Errorf("no feature report with id 0x%02x", b[0])
+	}
+	return copy(b, r), nil
+}
+
+// Close closes the transport. Pending and future calls to Read will return
+// ErrTransportClosed.
+func (f *FakeTransport) Close() error {
+	f.closeOnce.Do(func() {
+		close(f.closed)
+	})
+	return nil
+}
+
+// Serial returns the serial number supplied to NewFakeTransport.
+func (f *FakeTransport) Serial() string {
+	return f.serial
+}
+
+func (f *FakeTransport) isClosed() bool {
+	select {
+	case <-f.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func copyReports(in [][]byte) [][]byte {
+	out := make([][]byte, 0, len(in))
+	for _, r := range in {
+		c := make([]byte, len(r))
+		copy(c, r)
+		out = append(out, c)
+	}
+	return out
+}
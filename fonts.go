@@ -0,0 +1,417 @@
+package streamdeck
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// FontWeight is the weight of a font (100 -> 900) as in CSS / OpenType.
+type FontWeight int
+
+const (
+	WeightThin       FontWeight = 100
+	WeightExtraLight FontWeight = 200
+	WeightLight      FontWeight = 300
+	WeightRegular    FontWeight = 400
+	WeightMedium     FontWeight = 500
+	WeightSemiBold   FontWeight = 600
+	WeightBold       FontWeight = 700
+	WeightExtraBold  FontWeight = 800
+	WeightBlack      FontWeight = 900
+)
+
+// weightNames maps style names to weights. Compound names come first so
+// that e.g. "SemiBold" isn't taken for "Bold".
+var weightNames = []struct {
+	name   string
+	weight FontWeight
+}{
+	{"extralight", WeightExtraLight},
+	{"ultralight", WeightExtraLight},
+	{"semibold", WeightSemiBold},
+	{"demibold", WeightSemiBold},
+	{"extrabold", WeightExtraBold},
+	{"ultrabold", WeightExtraBold},
+	{"thin", WeightThin},
+	{"light", WeightLight},
+	{"medium", WeightMedium},
+	{"bold", WeightBold},
+	{"black", WeightBlack},
+	{"heavy", WeightBlack},
+}
+
+// styleWeight guesses the weight from the family and style (subfamily)
+// names of a font. Some fonts only mention the weight in the family name.
+func styleWeight(family, style string) FontWeight {
+	s := strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(family + style))
+	for _, w := range weightNames {
+		if strings.Contains(s, w.name) {
+			return w.weight
+		}
+	}
+	return WeightRegular
+}
+
+// FontInfo describes a registered font.
+type FontInfo struct {
+	Name   string
+	Family string
+	Style  string
+	Weight FontWeight
+}
+
+// registeredFont is either a TrueType font or an OpenType font (e.g. with
+// CFF outlines, which the truetype package can't handle).
+type registeredFont struct {
+	info FontInfo
+	tt   *truetype.Font
+	sf   *sfnt.Font
+}
+
+func (f *registeredFont) face(size float64) (font.Face, error) {
+	if f.tt != nil {
+		return truetype.NewFace(f.tt, &truetype.Options{Size: size, DPI: 72}), nil
+	}
+	return opentype.NewFace(f.sf, &opentype.FaceOptions{Size: size, DPI: 72})
+}
+
+func (f *registeredFont) hasGlyph(r rune) bool {
+	if f.tt != nil {
+		return f.tt.Index(r) != 0
+	}
+	i, err := f.sf.GlyphIndex(nil, r)
+	return err == nil && i != 0
+}
+
+// DefaultFontName is the name of the font used for TextLines without font.
+const DefaultFontName = "MonoRegular"
+
+// DefaultFontRegistry is the FontRegistry used for drawing text. The
+// embedded fonts are registered as "MonoRegular" and "MonoMedium".
+var DefaultFontRegistry = NewFontRegistry()
+
+// FontRegistry holds fonts by name and the fallback chains used for
+// glyphs which are missing in a font.
+type FontRegistry struct {
+	lock      sync.RWMutex
+	fonts     map[string]*registeredFont
+	names     map[*truetype.Font]string
+	fallbacks map[string][]string
+}
+
+// NewFontRegistry returns an empty FontRegistry.
+func NewFontRegistry() *FontRegistry {
+	return &FontRegistry{
+		fonts:     map[string]*registeredFont{},
+		names:     map[*truetype.Font]string{},
+		fallbacks: map[string][]string{},
+	}
+}
+
+// Register adds a parsed TrueType font under the given name. A font
+// registered before under the same name is replaced.
+func (fr *FontRegistry) Register(name string, f *truetype.Font) (FontInfo, error) {
+	if f == nil {
+		return FontInfo{}, fmt.Errorf("font %s is nil", name)
+	}
+	family := f.Name(truetype.NameIDFontFamily)
+	style := f.Name(truetype.NameIDFontSubfamily)
+	rf := &registeredFont{
+		info: FontInfo{Family: family, Style: style, Weight: styleWeight(family, style)},
+		tt:   f,
+	}
+	return fr.add(name, rf)
+}
+
+// RegisterBytes parses a TTF or OTF font and adds it under the given name.
+// If the name is empty, it is derived from the family and style of the font.
+func (fr *FontRegistry) RegisterBytes(name string, data []byte) (FontInfo, error) {
+	if f, err := truetype.Parse(data); err == nil {
+		return fr.Register(name, f)
+	}
+
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		return FontInfo{}, err
+	}
+	family, _ := f.Name(nil, sfnt.NameIDFamily)
+	style, _ := f.Name(nil, sfnt.NameIDSubfamily)
+	rf := &registeredFont{
+		info: FontInfo{Family: family, Style: style, Weight: styleWeight(family, style)},
+		sf:   f,
+	}
+	// fail early instead of while drawing
+	face, err := rf.face(DefaultFontSize)
+	if err != nil {
+		return FontInfo{}, err
+	}
+	face.Close()
+	return fr.add(name, rf)
+}
+
+// RegisterFile adds the TTF or OTF font in the file under the given name.
+// If the name is empty, the file name without extension is used.
+func (fr *FontRegistry) RegisterFile(name, path string) (FontInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return FontInfo{}, err
+	}
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	info, err := fr.RegisterBytes(name, data)
+	if err != nil {
+		return FontInfo{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return info, nil
+}
+
+// RegisterDir adds all .ttf and .otf files in the directory, named after
+// their file names. Files which can't be parsed are reported, but don't
+// prevent the other fonts from being registered.
+func (fr *FontRegistry) RegisterDir(dir string) ([]FontInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []FontInfo
+	var errs []error
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || (ext != ".ttf" && ext != ".otf") {
+			continue
+		}
+		info, err := fr.RegisterFile("", filepath.Join(dir, e.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos, errors.Join(errs...)
+}
+
+func (fr *FontRegistry) add(name string, rf *registeredFont) (FontInfo, error) {
+	if name == "" {
+		name = strings.TrimSpace(rf.info.Family + " " + rf.info.Style)
+	}
+	if name == "" {
+		return FontInfo{}, fmt.Errorf("font without name")
+	}
+	rf.info.Name = name
+
+	fr.lock.Lock()
+	defer fr.lock.Unlock()
+	fr.fonts[name] = rf
+	if rf.tt != nil {
+		fr.names[rf.tt] = name
+	}
+	return rf.info, nil
+}
+
+// Font returns the TrueType font registered under the name. OpenType fonts
+// with CFF outlines can only be used by name.
+func (fr *FontRegistry) Font(name string) (*truetype.Font, bool) {
+	fr.lock.RLock()
+	defer fr.lock.RUnlock()
+	rf, ok := fr.fonts[name]
+	if !ok || rf.tt == nil {
+		return nil, false
+	}
+	return rf.tt, true
+}
+
+// Info returns the description of the font registered under the name.
+func (fr *FontRegistry) Info(name string) (FontInfo, bool) {
+	fr.lock.RLock()
+	defer fr.lock.RUnlock()
+	rf, ok := fr.fonts[name]
+	if !ok {
+		return FontInfo{}, false
+	}
+	return rf.info, true
+}
+
+// Fonts returns the descriptions of all registered fonts, sorted by name.
+func (fr *FontRegistry) Fonts() []FontInfo {
+	fr.lock.RLock()
+	defer fr.lock.RUnlock()
+
+	infos := make([]FontInfo, 0, len(fr.fonts))
+	for _, rf := range fr.fonts {
+		infos = append(infos, rf.info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// Find returns the font of the family (case insensitive) whose weight is
+// closest to the requested weight. On a tie, the bolder font wins.
+func (fr *FontRegistry) Find(family string, weight FontWeight) (FontInfo, bool) {
+	var best FontInfo
+	found := false
+	distance := func(w FontWeight) int {
+		return max(int(w-weight), int(weight-w))
+	}
+	for _, info := range fr.Fonts() {
+		if !strings.EqualFold(info.Family, family) {
+			continue
+		}
+		if !found || distance(info.Weight) < distance(best.Weight) ||
+			(distance(info.Weight) == distance(best.Weight) && info.Weight > best.Weight) {
+			best = info
+			found = true
+		}
+	}
+	return best, found
+}
+
+// SetFallbacks defines the fonts which are used (in this order) for the
+// glyphs missing in the named font. All fonts have to be registered.
+func (fr *FontRegistry) SetFallbacks(name string, fallbacks ...string) error {
+	fr.lock.Lock()
+	defer fr.lock.Unlock()
+
+	for _, n := range append([]string{name}, fallbacks...) {
+		if _, ok := fr.fonts[n]; !ok {
+			return fmt.Errorf("font %s is not registered", n)
+		}
+	}
+	fr.fallbacks[name] = append([]string(nil), fallbacks...)
+	return nil
+}
+
+// lineFace returns the face of a TextLine including the fallback chain of
+// its font. The Font pointer takes precedence over the FontName. Unknown
+// names are replaced by the default font.
+func (fr *FontRegistry) lineFace(line TextLine, size float64) font.Face {
+	fr.lock.RLock()
+	defer fr.lock.RUnlock()
+
+	var primary *registeredFont
+	name := line.FontName
+	if line.Font != nil {
+		if n, ok := fr.names[line.Font]; ok {
+			name = n
+		} else {
+			primary = &registeredFont{tt: line.Font}
+			name = ""
+		}
+	}
+	if primary == nil {
+		if name == "" {
+			name = DefaultFontName
+		}
+		if primary = fr.fonts[name]; primary == nil {
+			primary = &registeredFont{tt: MonoRegular}
+		}
+	}
+
+	chain := []*registeredFont{primary}
+	for _, n := range fr.fallbacks[name] {
+		if rf, ok := fr.fonts[n]; ok {
+			chain = append(chain, rf)
+		}
+	}
+	return newFallbackFace(chain, size)
+}
+
+// fallbackFace draws each glyph with the first font of the chain which
+// contains it. Glyphs missing in all fonts are drawn with the first font.
+type fallbackFace struct {
+	fonts []*registeredFont
+	faces []font.Face
+}
+
+// newFallbackFace returns the face of the chain in the given size. Fonts
+// whose face can't be created are skipped, the default font is used if
+// none is left.
+func newFallbackFace(fonts []*registeredFont, size float64) *fallbackFace {
+	f := &fallbackFace{}
+	for _, rf := range fonts {
+		face, err := rf.face(size)
+		if err != nil {
+			debug("font %s: %v", rf.info.Name, err)
+			continue
+		}
+		f.fonts = append(f.fonts, rf)
+		f.faces = append(f.faces, face)
+	}
+	if len(f.faces) == 0 {
+		// TrueType faces can always be created
+		rf := &registeredFont{tt: MonoRegular}
+		face, _ := rf.face(size)
+		f.fonts = append(f.fonts, rf)
+		f.faces = append(f.faces, face)
+	}
+	return f
+}
+
+// pick returns the index of the face to use for the rune.
+func (f *fallbackFace) pick(r rune) (int, bool) {
+	for i, rf := range f.fonts {
+		if rf.hasGlyph(r) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (f *fallbackFace) Close() error {
+	var errs []error
+	for _, face := range f.faces {
+		errs = append(errs, face.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (f *fallbackFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	i, _ := f.pick(r)
+	return f.faces[i].Glyph(dot, r)
+}
+
+func (f *fallbackFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	i, _ := f.pick(r)
+	return f.faces[i].GlyphBounds(r)
+}
+
+func (f *fallbackFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	i, _ := f.pick(r)
+	return f.faces[i].GlyphAdvance(r)
+}
+
+func (f *fallbackFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	i0, _ := f.pick(r0)
+	i1, _ := f.pick(r1)
+	if i0 != i1 {
+		return 0
+	}
+	return f.faces[i0].Kern(r0, r1)
+}
+
+func (f *fallbackFace) Metrics() font.Metrics {
+	return f.faces[0].Metrics()
+}
+
+// hasGlyph returns true if any font of the face contains the rune.
+func hasGlyph(face font.Face, r rune) bool {
+	if f, ok := face.(*fallbackFace); ok {
+		_, found := f.pick(r)
+		return found
+	}
+	_, ok := face.GlyphAdvance(r)
+	return ok
+}
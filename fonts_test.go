@@ -0,0 +1,101 @@
+package streamdeck
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.viam.com/test"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/math/fixed"
+)
+
+func TestFontRegistryFind(t *testing.T) {
+	fr := NewFontRegistry()
+	info, err := fr.RegisterBytes("", goregular.TTF)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, info, test.ShouldResemble, FontInfo{Name: "Go Regular", Family: "Go", Style: "Regular", Weight: WeightRegular})
+	_, err = fr.RegisterBytes("GoBold", gobold.TTF)
+	test.That(t, err, test.ShouldBeNil)
+
+	_, err = fr.RegisterBytes("broken", []byte("no font"))
+	test.That(t, err, test.ShouldNotBeNil)
+
+	found, ok := fr.Find("go", WeightSemiBold)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, found.Name, test.ShouldEqual, "GoBold")
+	found, _ = fr.Find("Go", WeightLight)
+	test.That(t, found.Name, test.ShouldEqual, "Go Regular")
+	_, ok = fr.Find("Helvetica", WeightRegular)
+	test.That(t, ok, test.ShouldBeFalse)
+
+	names := []string{}
+	for _, info := range fr.Fonts() {
+		names = append(names, info.Name)
+	}
+	test.That(t, names, test.ShouldResemble, []string{"Go Regular", "GoBold"})
+	f, ok := fr.Font("GoBold")
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, f, test.ShouldNotBeNil)
+}
+
+func TestFontRegistryDir(t *testing.T) {
+	dir := t.TempDir()
+	test.That(t, os.WriteFile(filepath.Join(dir, "regular.ttf"), goregular.TTF, 0o644), test.ShouldBeNil)
+	test.That(t, os.WriteFile(filepath.Join(dir, "bold.TTF"), gobold.TTF, 0o644), test.ShouldBeNil)
+	test.That(t, os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("fonts"), 0o644), test.ShouldBeNil)
+	test.That(t, os.WriteFile(filepath.Join(dir, "broken.otf"), []byte("no font"), 0o644), test.ShouldBeNil)
+
+	fr := NewFontRegistry()
+	infos, err := fr.RegisterDir(dir)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, len(infos), test.ShouldEqual, 2)
+	info, ok := fr.Info("bold")
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, info.Weight, test.ShouldEqual, WeightBold)
+	_, ok = fr.Info("broken")
+	test.That(t, ok, test.ShouldBeFalse)
+}
+
+func TestFontFallback(t *testing.T) {
+	fr := NewFontRegistry()
+	_, err := fr.RegisterBytes("Go", goregular.TTF)
+	test.That(t, err, test.ShouldBeNil)
+	_, err = fr.Register("Mono", MonoRegular)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, fr.SetFallbacks("Go", "Unknown"), test.ShouldNotBeNil)
+
+	// without fallback, the missing glyph is drawn with the primary font
+	face := fr.lineFace(TextLine{FontName: "Go"}, 20)
+	test.That(t, hasGlyph(face, '日'), test.ShouldBeFalse)
+
+	test.That(t, fr.SetFallbacks("Go", "Mono"), test.ShouldBeNil)
+	face = fr.lineFace(TextLine{FontName: "Go"}, 20)
+	test.That(t, hasGlyph(face, '日'), test.ShouldBeTrue)
+	adv, _ := face.GlyphAdvance('日')
+	monoAdv, _ := fr.lineFace(TextLine{FontName: "Mono"}, 20).GlyphAdvance('日')
+	test.That(t, adv, test.ShouldEqual, monoAdv)
+	test.That(t, face.Metrics(), test.ShouldResemble, fr.lineFace(TextLine{FontName: "Go"}, 20).Metrics())
+
+	// the Font pointer is resolved to its registered name and fallbacks
+	goFont, _ := fr.Font("Go")
+	face = fr.lineFace(TextLine{Font: goFont, FontName: "Mono"}, 20)
+	adv, _ = face.GlyphAdvance('i')
+	goAdv, _ := fr.lineFace(TextLine{FontName: "Go"}, 20).GlyphAdvance('i')
+	test.That(t, adv, test.ShouldEqual, goAdv)
+	test.That(t, hasGlyph(face, '日'), test.ShouldBeTrue)
+}
+
+func TestTextLineFontName(t *testing.T) {
+	_, err := DefaultFontRegistry.RegisterBytes("test-go-bold", gobold.TTF)
+	test.That(t, err, test.ShouldBeNil)
+
+	byName, _ := MeasureText(nil, 20, "WWW")
+	runs := absoluteRuns(solidImage(120, black).Bounds(), []TextLine{{Text: "WWW", FontName: "test-go-bold", FontSize: 20}})
+	test.That(t, runs[0].width, test.ShouldNotEqual, fixed.I(byName))
+
+	// unknown names use the default font
+	runs = absoluteRuns(solidImage(120, black).Bounds(), []TextLine{{Text: "WWW", FontName: "missing", FontSize: 20}})
+	test.That(t, runs[0].width.Ceil(), test.ShouldEqual, byName)
+}
@@ -0,0 +1,170 @@
+package streamdeck
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"sort"
+)
+
+// isShownInLock returns true if the key already shows exactly this image.
+func (sd *StreamDeck) isShownInLock(btnIndex int, imgBuf []byte) bool {
+	shown, ok := sd.shown[btnIndex]
+	return ok && bytes.Equal(shown, imgBuf)
+}
+
+// sendImageInLock sends an encoded key image to the device unless the key
+// already shows exactly this image.
+func (sd *StreamDeck) sendImageInLock(btnIndex int, imgBuf []byte) error {
+	if sd.isShownInLock(btnIndex, imgBuf) {
+		return nil
+	}
+	if err := sd.writeImageInLock(btnIndex, imgBuf); err != nil {
+		// the key might show a partial image now
+		delete(sd.shown, btnIndex)
+		return err
+	}
+	sd.shown[btnIndex] = imgBuf
+	return nil
+}
+
+// Invalidate forgets which images are currently shown on the keys, so that
+// the next image written to each key is sent to the device even if it
+// hasn't changed.
+func (sd *StreamDeck) Invalidate() {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	sd.shown = map[int][]byte{}
+}
+
+// Batch collects the images of several keys, which are then sent to the
+// device at once with Flush. Keys whose image hasn't changed are skipped.
+// A Batch must not be used concurrently.
+type Batch struct {
+	sd     *StreamDeck
+	images map[int][]byte
+}
+
+// Batch returns a new, empty Batch for the Stream Deck.
+func (sd *StreamDeck) Batch() *Batch {
+	return &Batch{
+		sd:     sd,
+		images: map[int][]byte{},
+	}
+}
+
+// FillImage sets the image of a key. The image is scaled (if necessary)
+// and encoded immediately, but only sent to the device on Flush.
+func (b *Batch) FillImage(btnIndex int, img image.Image) error {
+	if err := b.sd.checkValidKeyIndex(btnIndex); err != nil {
+		return err
+	}
+
+	rect := img.Bounds()
+	if rect.Dx() != b.sd.Config.ButtonSize {
+		img = resize(img, b.sd.Config.ButtonSize, b.sd.Config.ButtonSize)
+	}
+
+	imgBuf, err := b.sd.encodeImage(img)
+	if err != nil {
+		return err
+	}
+	b.images[btnIndex] = imgBuf
+	return nil
+}
+
+// FillColor sets the key to a solid color.
+func (b *Batch) FillColor(btnIndex, red, green, blue int) error {
+	img, err := b.sd.colorImage(red, green, blue)
+	if err != nil {
+		return err
+	}
+	return b.FillImage(btnIndex, img)
+}
+
+// Len returns the number of keys set in the Batch.
+func (b *Batch) Len() int {
+	return len(b.images)
+}
+
+// Flush sends the images of all keys set in the Batch to the device and
+// empties the Batch. The images are stored even if the Stream Deck is
+// disconnected (ErrDisconnected) and will be applied once it has been
+// reconnected. Failed keys are reported as KeyError.
+func (b *Batch) Flush() error {
+	images := b.images
+	b.images = map[int][]byte{}
+	b.sd.stopAnimations(imageKeys(images)...)
+	errs := b.sd.commitImages(images)
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+// KeyError is returned when the image of a particular key could not be
+// written to the device.
+type KeyError struct {
+	Key int
+	Err error
+}
+
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("key %d: %v", e.Key, e.Err)
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// imageKeys returns the keys of the images in ascending order.
+func imageKeys(images map[int][]byte) []int {
+	keys := make([]int, 0, len(images))
+	for key := range images {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// commitImages stores the encoded images of several keys and sends them to
+// the device in ascending key order.
+func (sd *StreamDeck) commitImages(images map[int][]byte) []error {
+	_, _, errs := sd.commitImagesCounted(images)
+	return errs
+}
+
+// commitImagesCounted is commitImages, which additionally returns the
+// number of images written to the device and the number of images skipped
+// because their keys already showed them.
+func (sd *StreamDeck) commitImagesCounted(images map[int][]byte) (sent, skipped int, errs []error) {
+	keys := imageKeys(images)
+
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+
+	for _, key := range keys {
+		sd.keyImages[key] = images[key]
+	}
+	if !sd.connected {
+		return 0, 0, []error{ErrDisconnected}
+	}
+	if sd.sleeping {
+		// shown on wake up
+		return 0, 0, nil
+	}
+
+	for _, key := range keys {
+		if sd.isShownInLock(key, images[key]) {
+			skipped++
+			continue
+		}
+		if err := sd.sendImageInLock(key, images[key]); err != nil {
+			errs = append(errs, &KeyError{Key: key, Err: err})
+			continue
+		}
+		sent++
+	}
+	return sent, skipped, errs
+}
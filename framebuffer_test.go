@@ -0,0 +1,59 @@
+package streamdeck
+
+import (
+	"image/color"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestFillImageSkipsUnchanged(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+	red := solidImage(72, color.RGBA{255, 0, 0, 255})
+
+	test.That(t, sd.FillImage(3, red), test.ShouldBeNil)
+	test.That(t, writtenKeys(ft), test.ShouldResemble, map[int]bool{3: true})
+	ft.Reset()
+
+	test.That(t, sd.FillImage(3, red), test.ShouldBeNil)
+	test.That(t, ft.Written(), test.ShouldBeEmpty)
+
+	// the keys are already black after construction
+	test.That(t, sd.ClearBtn(4), test.ShouldBeNil)
+	test.That(t, ft.Written(), test.ShouldBeEmpty)
+
+	sd.Invalidate()
+	test.That(t, sd.FillImage(3, red), test.ShouldBeNil)
+	test.That(t, writtenKeys(ft), test.ShouldResemble, map[int]bool{3: true})
+}
+
+func TestBatchFlush(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+
+	b := sd.Batch()
+	test.That(t, b.FillColor(0, 255, 0, 0), test.ShouldBeNil)
+	test.That(t, b.FillColor(1, 0, 0, 0), test.ShouldBeNil)
+	test.That(t, b.FillImage(2, solidImage(72, color.RGBA{0, 0, 255, 255})), test.ShouldBeNil)
+	test.That(t, b.FillColor(99, 0, 0, 0), test.ShouldNotBeNil)
+	test.That(t, b.Len(), test.ShouldEqual, 3)
+
+	// nothing is written before the flush
+	test.That(t, ft.Written(), test.ShouldBeEmpty)
+
+	test.That(t, b.Flush(), test.ShouldBeNil)
+	test.That(t, b.Len(), test.ShouldEqual, 0)
+	// key 1 is already black
+	test.That(t, writtenKeys(ft), test.ShouldResemble, map[int]bool{0: true, 2: true})
+}
+
+func TestClearAllBtnsSkipsUnchanged(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+
+	test.That(t, sd.ClearAllBtns(), test.ShouldBeNil)
+	test.That(t, ft.Written(), test.ShouldBeEmpty)
+
+	test.That(t, sd.FillColor(7, 0, 255, 0), test.ShouldBeNil)
+	ft.Reset()
+	test.That(t, sd.ClearAllBtns(), test.ShouldBeNil)
+	test.That(t, writtenKeys(ft), test.ShouldResemble, map[int]bool{7: true})
+}
@@ -0,0 +1,314 @@
+package streamdeck
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock abstracts the passing of time, so that the GestureRecognizer can
+// be tested deterministically.
+type Clock interface {
+	Now() time.Time
+	// AfterFunc executes f in its own go routine after the duration d.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a timer created by Clock.AfterFunc.
+type Timer interface {
+	Stop() bool
+}
+
+// realClock implements Clock with the functions of the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// GestureConfig holds the thresholds of the gestures recognized on a key
+// or dial. A zero duration disables the corresponding gesture.
+type GestureConfig struct {
+	// LongPress is the time a key has to be held down to emit a long press.
+	LongPress time.Duration
+	// DoublePress is the maximum time between releasing a key and
+	// pressing it again to emit a double press.
+	DoublePress time.Duration
+	// RepeatDelay is the time after which a held down key starts repeating.
+	RepeatDelay time.Duration
+	// RepeatInterval is the time between two repeats. If zero, RepeatDelay
+	// is used.
+	RepeatInterval time.Duration
+}
+
+// DefaultGestureConfig are the gesture thresholds used for all keys and
+// dials which have not been configured otherwise.
+var DefaultGestureConfig = GestureConfig{
+	LongPress:   500 * time.Millisecond,
+	DoublePress: 250 * time.Millisecond,
+}
+
+type gestureInput struct {
+	dial  bool
+	which int
+}
+
+type gestureState struct {
+	down     bool
+	gen      int  // incremented with every press, invalidates running timers
+	held     bool // a long press or repeat has been emitted for the current press
+	double   bool // the current press has been emitted as double press
+	released time.Time
+	timers   []Timer
+	repeat   Timer // replaced with every repeat
+}
+
+func (st *gestureState) stopTimers() {
+	for _, t := range st.timers {
+		t.Stop()
+	}
+	st.timers = nil
+	if st.repeat != nil {
+		st.repeat.Stop()
+		st.repeat = nil
+	}
+}
+
+// GestureRecognizer consumes the raw key and dial push events and emits
+// taps, long presses, double presses and repeats. Taps are only emitted
+// once it is clear that the press will not become a long or double press,
+// so that each key can trigger different actions for the different gestures.
+type GestureRecognizer struct {
+	lock     sync.Mutex
+	clock    Clock
+	emit     func(Event)
+	defaults GestureConfig
+	configs  map[gestureInput]GestureConfig
+	states   map[gestureInput]*gestureState
+	pending  []Event // recognized gestures which haven't been emitted yet
+	emitting bool    // a go routine is emitting the pending gestures
+}
+
+// NewGestureRecognizer returns a GestureRecognizer which uses the defaults
+// for all keys and dials and calls emit for every recognized gesture. If
+// clock is nil, the system clock is used. The gestures are recognized in
+// Process and in the go routines of the timers, but emit is never called
+// concurrently and receives the gestures in the order they were recognized.
+// It may call Process itself.
+func NewGestureRecognizer(defaults GestureConfig, clock Clock, emit func(Event)) *GestureRecognizer {
+	if clock == nil {
+		clock = realClock{}
+	}
+	return &GestureRecognizer{
+		clock:    clock,
+		emit:     emit,
+		defaults: defaults,
+		configs:  map[gestureInput]GestureConfig{},
+		states:   map[gestureInput]*gestureState{},
+	}
+}
+
+// SetKeyConfig sets the gesture thresholds for a particular key.
+func (g *GestureRecognizer) SetKeyConfig(keyIndex int, c GestureConfig) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.configs[gestureInput{which: keyIndex}] = c
+}
+
+// SetDialConfig sets the gesture thresholds for pushing a particular dial.
+func (g *GestureRecognizer) SetDialConfig(dialIndex int, c GestureConfig) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.configs[gestureInput{dial: true, which: dialIndex}] = c
+}
+
+// Run feeds all events received on the channel into the recognizer until
+// the channel is closed or ctx is cancelled.
+func (g *GestureRecognizer) Run(ctx context.Context, events <-chan StateEvent) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			g.Process(e.Event)
+		}
+	}
+}
+
+// Process feeds a raw event into the recognizer. Events other than key
+// and dial presses / releases are ignored.
+func (g *GestureRecognizer) Process(e Event) {
+	var out []Event
+
+	g.lock.Lock()
+	switch e.Kind {
+	case EventKeyPressed:
+		out = g.pressInLock(gestureInput{which: e.Which})
+	case EventKeyReleased:
+		out = g.releaseInLock(gestureInput{which: e.Which})
+	case EventDialPressed:
+		out = g.pressInLock(gestureInput{dial: true, which: e.Which})
+	case EventDialReleased:
+		out = g.releaseInLock(gestureInput{dial: true, which: e.Which})
+	}
+	g.pending = append(g.pending, out...)
+	g.lock.Unlock()
+
+	g.emitPending()
+}
+
+func (g *GestureRecognizer) configInLock(in gestureInput) GestureConfig {
+	if c, ok := g.configs[in]; ok {
+		return c
+	}
+	return g.defaults
+}
+
+func (g *GestureRecognizer) stateInLock(in gestureInput) *gestureState {
+	st, ok := g.states[in]
+	if !ok {
+		st = &gestureState{}
+		g.states[in] = st
+	}
+	return st
+}
+
+func (g *GestureRecognizer) pressInLock(in gestureInput) []Event {
+	c := g.configInLock(in)
+	st := g.stateInLock(in)
+	now := g.clock.Now()
+
+	// a pending tap timer means that the key has been released recently
+	isDouble := c.DoublePress > 0 && len(st.timers) > 0 && !st.down &&
+		now.Sub(st.released) <= c.DoublePress
+
+	st.stopTimers()
+	st.gen++
+	st.down = true
+	st.held = false
+	st.double = isDouble
+
+	var out []Event
+	if isDouble {
+		out = append(out, gestureEvent(in, EventKeyDoublePress))
+	}
+
+	gen := st.gen
+	if c.LongPress > 0 {
+		st.timers = append(st.timers, g.clock.AfterFunc(c.LongPress, func() {
+			g.fire(in, gen, EventKeyLongPress, 0)
+		}))
+	}
+	if c.RepeatDelay > 0 {
+		interval := c.RepeatInterval
+		if interval <= 0 {
+			interval = c.RepeatDelay
+		}
+		st.repeat = g.clock.AfterFunc(c.RepeatDelay, func() {
+			g.fire(in, gen, EventKeyRepeat, interval)
+		})
+	}
+	return out
+}
+
+func (g *GestureRecognizer) releaseInLock(in gestureInput) []Event {
+	c := g.configInLock(in)
+	st := g.stateInLock(in)
+
+	st.stopTimers()
+	st.gen++
+	wasDown := st.down
+	st.down = false
+	st.released = g.clock.Now()
+
+	if !wasDown || st.held || st.double {
+		return nil
+	}
+
+	if c.DoublePress <= 0 {
+		return []Event{gestureEvent(in, EventKeyTap)}
+	}
+
+	// wait if the key gets pressed a second time before emitting the tap
+	gen := st.gen
+	st.timers = append(st.timers, g.clock.AfterFunc(c.DoublePress, func() {
+		g.fire(in, gen, EventKeyTap, 0)
+	}))
+	return nil
+}
+
+// fire is executed by the timers. If repeat is non-zero, the timer is
+// restarted with this interval.
+func (g *GestureRecognizer) fire(in gestureInput, gen int, kind EventKind, repeat time.Duration) {
+	g.lock.Lock()
+	st := g.stateInLock(in)
+	if st.gen != gen {
+		g.lock.Unlock()
+		return
+	}
+	switch kind {
+	case EventKeyTap:
+		st.timers = nil
+	case EventKeyLongPress, EventKeyRepeat:
+		st.held = true
+	}
+	if repeat > 0 {
+		st.repeat = g.clock.AfterFunc(repeat, func() {
+			g.fire(in, gen, kind, repeat)
+		})
+	}
+	g.pending = append(g.pending, gestureEvent(in, kind))
+	g.lock.Unlock()
+
+	g.emitPending()
+}
+
+// emitPending emits the pending gestures in order. If another go routine
+// (or a call of Process from emit) is already emitting, the gestures are
+// left to it, so that emit is never called concurrently.
+func (g *GestureRecognizer) emitPending() {
+	g.lock.Lock()
+	if g.emitting {
+		g.lock.Unlock()
+		return
+	}
+	g.emitting = true
+	for len(g.pending) > 0 {
+		events := g.pending
+		g.pending = nil
+		g.lock.Unlock()
+		if g.emit != nil {
+			for _, e := range events {
+				g.emit(e)
+			}
+		}
+		g.lock.Lock()
+	}
+	g.emitting = false
+	g.lock.Unlock()
+}
+
+// gestureEvent returns the event of the given key gesture kind, translated
+// into the corresponding dial gesture kind if necessary.
+func gestureEvent(in gestureInput, kind EventKind) Event {
+	if in.dial {
+		switch kind {
+		case EventKeyTap:
+			kind = EventDialTap
+		case EventKeyLongPress:
+			kind = EventDialLongPress
+		case EventKeyDoublePress:
+			kind = EventDialDoublePress
+		case EventKeyRepeat:
+			kind = EventDialRepeat
+		}
+	}
+	return Event{Kind: kind, Which: in.which}
+}
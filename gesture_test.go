@@ -0,0 +1,244 @@
+package streamdeck
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+)
+
+// fakeClock is a Clock which only advances when told to. Expired timers
+// are executed synchronously by Advance.
+type fakeClock struct {
+	lock   sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock    *fakeClock
+	deadline time.Time
+	f        func()
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	t := &fakeTimer{clock: c, deadline: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+	for i, other := range t.clock.timers {
+		if other == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	end := c.now.Add(d)
+	for {
+		sort.Slice(c.timers, func(i, j int) bool {
+			return c.timers[i].deadline.Before(c.timers[j].deadline)
+		})
+		if len(c.timers) == 0 || c.timers[0].deadline.After(end) {
+			break
+		}
+		t := c.timers[0]
+		c.timers = c.timers[1:]
+		c.now = t.deadline
+		c.lock.Unlock()
+		t.f()
+		c.lock.Lock()
+	}
+	c.now = end
+	c.lock.Unlock()
+}
+
+type gestureRecorder struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func (r *gestureRecorder) emit(e Event) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.events = append(r.events, e.String())
+}
+
+func (r *gestureRecorder) take() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	ev := r.events
+	r.events = nil
+	if ev == nil {
+		return []string{}
+	}
+	return ev
+}
+
+func TestGestureTap(t *testing.T) {
+	clock := newFakeClock()
+	rec := &gestureRecorder{}
+	g := NewGestureRecognizer(DefaultGestureConfig, clock, rec.emit)
+
+	g.Process(Event{Kind: EventKeyPressed, Which: 3})
+	clock.Advance(100 * time.Millisecond)
+	g.Process(Event{Kind: EventKeyReleased, Which: 3})
+	test.That(t, rec.take(), test.ShouldResemble, []string{})
+
+	// the tap is emitted once the double press window has passed
+	clock.Advance(300 * time.Millisecond)
+	test.That(t, rec.take(), test.ShouldResemble, []string{"key-tap:3"})
+}
+
+func TestGestureLongPress(t *testing.T) {
+	clock := newFakeClock()
+	rec := &gestureRecorder{}
+	g := NewGestureRecognizer(DefaultGestureConfig, clock, rec.emit)
+
+	g.Process(Event{Kind: EventKeyPressed, Which: 1})
+	clock.Advance(499 * time.Millisecond)
+	test.That(t, rec.take(), test.ShouldResemble, []string{})
+	clock.Advance(time.Millisecond)
+	test.That(t, rec.take(), test.ShouldResemble, []string{"key-long-press:1"})
+
+	g.Process(Event{Kind: EventKeyReleased, Which: 1})
+	clock.Advance(time.Second)
+	test.That(t, rec.take(), test.ShouldResemble, []string{})
+}
+
+func TestGestureDoublePress(t *testing.T) {
+	clock := newFakeClock()
+	rec := &gestureRecorder{}
+	g := NewGestureRecognizer(DefaultGestureConfig, clock, rec.emit)
+
+	g.Process(Event{Kind: EventKeyPressed, Which: 0})
+	clock.Advance(50 * time.Millisecond)
+	g.Process(Event{Kind: EventKeyReleased, Which: 0})
+	clock.Advance(200 * time.Millisecond)
+	g.Process(Event{Kind: EventKeyPressed, Which: 0})
+	test.That(t, rec.take(), test.ShouldResemble, []string{"key-double-press:0"})
+	clock.Advance(50 * time.Millisecond)
+	g.Process(Event{Kind: EventKeyReleased, Which: 0})
+	clock.Advance(time.Second)
+	test.That(t, rec.take(), test.ShouldResemble, []string{})
+
+	// too slow for a double press
+	g.Process(Event{Kind: EventKeyPressed, Which: 0})
+	g.Process(Event{Kind: EventKeyReleased, Which: 0})
+	clock.Advance(260 * time.Millisecond)
+	g.Process(Event{Kind: EventKeyPressed, Which: 0})
+	g.Process(Event{Kind: EventKeyReleased, Which: 0})
+	clock.Advance(260 * time.Millisecond)
+	test.That(t, rec.take(), test.ShouldResemble, []string{"key-tap:0", "key-tap:0"})
+}
+
+func TestGestureRepeatPerKey(t *testing.T) {
+	clock := newFakeClock()
+	rec := &gestureRecorder{}
+	g := NewGestureRecognizer(GestureConfig{}, clock, rec.emit)
+	g.SetKeyConfig(7, GestureConfig{RepeatDelay: 400 * time.Millisecond, RepeatInterval: 100 * time.Millisecond})
+
+	// without thresholds, a key emits a tap immediately
+	g.Process(Event{Kind: EventKeyPressed, Which: 2})
+	g.Process(Event{Kind: EventKeyReleased, Which: 2})
+	test.That(t, rec.take(), test.ShouldResemble, []string{"key-tap:2"})
+
+	g.Process(Event{Kind: EventKeyPressed, Which: 7})
+	clock.Advance(650 * time.Millisecond)
+	test.That(t, rec.take(), test.ShouldResemble, []string{"key-repeat:7", "key-repeat:7", "key-repeat:7"})
+
+	// holding the key doesn't accumulate timers
+	clock.Advance(10 * time.Second)
+	test.That(t, len(rec.take()), test.ShouldEqual, 100)
+	g.lock.Lock()
+	test.That(t, len(g.states[gestureInput{which: 7}].timers), test.ShouldEqual, 0)
+	g.lock.Unlock()
+	clock.lock.Lock()
+	test.That(t, len(clock.timers), test.ShouldEqual, 1)
+	clock.lock.Unlock()
+	g.Process(Event{Kind: EventKeyReleased, Which: 7})
+	clock.Advance(time.Second)
+	test.That(t, rec.take(), test.ShouldResemble, []string{})
+}
+
+func TestGestureDialPush(t *testing.T) {
+	clock := newFakeClock()
+	rec := &gestureRecorder{}
+	g := NewGestureRecognizer(DefaultGestureConfig, clock, rec.emit)
+	g.SetDialConfig(2, GestureConfig{LongPress: time.Second})
+
+	g.Process(Event{Kind: EventDialPressed, Which: 2})
+	clock.Advance(time.Second)
+	g.Process(Event{Kind: EventDialReleased, Which: 2})
+
+	g.Process(Event{Kind: EventDialPressed, Which: 1})
+	g.Process(Event{Kind: EventDialReleased, Which: 1})
+	g.Process(Event{Kind: EventDialPressed, Which: 1})
+	g.Process(Event{Kind: EventDialReleased, Which: 1})
+
+	// dial 1 and key 1 are independent
+	g.Process(Event{Kind: EventKeyPressed, Which: 1})
+	g.Process(Event{Kind: EventKeyReleased, Which: 1})
+	clock.Advance(time.Second)
+
+	test.That(t, rec.take(), test.ShouldResemble, []string{"dial-long-press:2", "dial-double-press:1", "key-tap:1"})
+}
+
+func TestGestureEmitOrdered(t *testing.T) {
+	clock := newFakeClock()
+	rec := &gestureRecorder{}
+	var g *GestureRecognizer
+	g = NewGestureRecognizer(DefaultGestureConfig, clock, func(e Event) {
+		// a gesture recognized while emitting is emitted afterwards
+		if e.Kind == EventKeyLongPress {
+			g.Process(Event{Kind: EventKeyPressed, Which: 2})
+			g.Process(Event{Kind: EventKeyReleased, Which: 2})
+			g.Process(Event{Kind: EventKeyPressed, Which: 2})
+		}
+		rec.emit(e)
+	})
+
+	g.Process(Event{Kind: EventKeyPressed, Which: 1})
+	clock.Advance(500 * time.Millisecond)
+	test.That(t, rec.take(), test.ShouldResemble, []string{"key-long-press:1", "key-double-press:2"})
+}
+
+func TestGestureRun(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gestures := make(chan Event, 1)
+	g := NewGestureRecognizer(GestureConfig{}, nil, func(e Event) {
+		gestures <- e
+	})
+	go g.Run(ctx, sd.Events(ctx))
+
+	ft.Inject(keyReport(Original2, 4))
+	ft.Inject(keyReport(Original2))
+
+	test.That(t, (<-gestures).String(), test.ShouldEqual, "key-tap:4")
+}
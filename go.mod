@@ -7,13 +7,13 @@ require (
 	github.com/disintegration/gift v1.2.1
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
 	go.viam.com/test v1.2.4
+	golang.org/x/image v0.31.0
 )
 
 require (
 	github.com/dgottlieb/smarty-assertions v1.2.6 // indirect
 	github.com/google/go-cmp v0.7.0 // indirect
-	golang.org/x/image v0.31.0 // indirect
 	golang.org/x/sys v0.36.0 // indirect
-	golang.org/x/xerrors v0.0.0-20240903120638-7835f813f4da // indirect
+	golang.org/x/text v0.29.0 // indirect
 	google.golang.org/protobuf v1.36.9 // indirect
 )
@@ -0,0 +1,187 @@
+package streamdeck
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// Icon is a vector graphic which can be rendered at any size.
+type Icon interface {
+	// DrawIcon draws the icon centered into rect, scaled as large as
+	// possible while keeping its aspect ratio. If tint is not nil, the
+	// icon is drawn in tint.
+	DrawIcon(dst draw.Image, rect image.Rectangle, tint color.Color)
+}
+
+// GlyphIcon is a single glyph of a font in DefaultFontRegistry, typically
+// of an icon font. Without tint, it is drawn in white.
+type GlyphIcon struct {
+	Font string
+	Rune rune
+}
+
+// DrawIcon implements Icon. The glyph is scaled so that its ink (not its
+// advance) fills rect.
+func (g GlyphIcon) DrawIcon(dst draw.Image, rect image.Rectangle, tint color.Color) {
+	if tint == nil {
+		tint = color.White
+	}
+	line := TextLine{FontName: g.Font}
+
+	// measure at a reference size, then scale to fit
+	const refSize = 100
+	b, _, ok := lineFace(line, refSize).GlyphBounds(g.Rune)
+	w, h := float64(b.Max.X-b.Min.X)/64, float64(b.Max.Y-b.Min.Y)/64
+	if !ok || w <= 0 || h <= 0 || rect.Empty() {
+		return
+	}
+	size := refSize * math.Min(float64(rect.Dx())/w, float64(rect.Dy())/h)
+
+	face := lineFace(line, size)
+	b, _, _ = face.GlyphBounds(g.Rune)
+	dot := fixed.Point26_6{
+		X: fixed.I(rect.Min.X) + (fixed.I(rect.Dx())-(b.Max.X-b.Min.X))/2 - b.Min.X,
+		Y: fixed.I(rect.Min.Y) + (fixed.I(rect.Dy())-(b.Max.Y-b.Min.Y))/2 - b.Min.Y,
+	}
+	d := font.Drawer{Dst: dst, Src: image.NewUniform(tint), Face: face, Dot: dot}
+	d.DrawString(string(g.Rune))
+}
+
+// IconFont looks up the glyphs of an icon font (registered in
+// DefaultFontRegistry) by their names.
+type IconFont struct {
+	Font       string
+	Codepoints map[string]rune
+}
+
+// LoadIconFont registers the font file under name and reads the names of
+// its icons from a codepoints file (see ParseCodepoints).
+func LoadIconFont(name, fontPath, codepointsPath string) (*IconFont, error) {
+	if _, err := DefaultFontRegistry.RegisterFile(name, fontPath); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(codepointsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	codepoints, err := ParseCodepoints(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", codepointsPath, err)
+	}
+	return &IconFont{Font: name, Codepoints: codepoints}, nil
+}
+
+// ParseCodepoints reads a codepoints file as shipped with Material Icons:
+// one icon per line with its name and hexadecimal codepoint, separated by
+// whitespace.
+func ParseCodepoints(r io.Reader) (map[string]rune, error) {
+	codepoints := map[string]rune{}
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected name and codepoint", n)
+		}
+		cp, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(fields[1]), "0x"), 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid codepoint %s", n, fields[1])
+		}
+		codepoints[fields[0]] = rune(cp)
+	}
+	return codepoints, sc.Err()
+}
+
+// Icon returns the icon with the given name.
+func (f *IconFont) Icon(name string) (GlyphIcon, error) {
+	r, ok := f.Codepoints[name]
+	if !ok {
+		return GlyphIcon{}, fmt.Errorf("unknown icon %s", name)
+	}
+	return GlyphIcon{Font: f.Font, Rune: r}, nil
+}
+
+// IconButton is an icon with an optional label below it. Padding and
+// LabelSize refer to a key of 72x72 pixel and are scaled to the ButtonSize
+// of the Stream Deck, so that a button looks the same on every model.
+type IconButton struct {
+	Icon    Icon
+	Tint    color.Color
+	BgColor color.Color
+	// Padding (in pixel) between the content and the key border.
+	Padding int
+	Label   string
+	// LabelFont is the name of the font in DefaultFontRegistry.
+	LabelFont string
+	// LabelSize defaults to 12.
+	LabelSize  float64
+	LabelColor color.Color
+}
+
+// RenderIconButton renders the IconButton onto a key of size x size pixel.
+func RenderIconButton(size int, btn IconButton) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	btn.Draw(img, img.Bounds())
+	return img
+}
+
+// Draw implements Layer.
+func (btn IconButton) Draw(dst draw.Image, rect image.Rectangle) {
+	if btn.BgColor != nil {
+		draw.Draw(dst, rect, image.NewUniform(btn.BgColor), image.Point{}, draw.Over)
+	}
+	scale := layoutScale(rect)
+	inner := rect.Inset(int(math.Round(float64(btn.Padding) * scale)))
+	iconRect := inner
+
+	if btn.Label != "" {
+		labelSize := btn.LabelSize
+		if labelSize <= 0 {
+			labelSize = 12
+		}
+		line := TextLine{
+			Text:      btn.Label,
+			FontName:  btn.LabelFont,
+			FontSize:  labelSize * scale,
+			FontColor: btn.LabelColor,
+		}
+		m := lineFace(line, line.FontSize).Metrics()
+		labelRect := inner
+		labelRect.Min.Y = inner.Max.Y - (m.Ascent + m.Descent).Ceil()
+		DrawText(dst, labelRect, []TextLine{line}, &TextLayout{
+			HAlign:      AlignCenter,
+			VAlign:      AlignBottom,
+			MinFontSize: line.FontSize * 0.6,
+			Ellipsis:    true,
+		})
+		// keep a small gap between icon and label
+		iconRect.Max.Y = labelRect.Min.Y - int(math.Round(2*scale))
+	}
+
+	if btn.Icon != nil {
+		btn.Icon.DrawIcon(dst, iconRect, btn.Tint)
+	}
+}
+
+// WriteIcon renders an IconButton onto a key.
+func (sd *StreamDeck) WriteIcon(btnIndex int, btn IconButton) error {
+	if err := sd.checkValidKeyIndex(btnIndex); err != nil {
+		return err
+	}
+	return sd.FillImage(btnIndex, RenderIconButton(sd.Config.ButtonSize, btn))
+}
@@ -0,0 +1,73 @@
+package streamdeck
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestGlyphIcon(t *testing.T) {
+	tint := color.RGBA{255, 200, 0, 255}
+	img := solidImage(72, black)
+	rect := image.Rect(10, 10, 62, 62)
+	GlyphIcon{Font: DefaultFontName, Rune: 'H'}.DrawIcon(img, rect, tint)
+
+	ink := inkBounds(img, black)
+	test.That(t, ink.In(rect), test.ShouldBeTrue)
+	// the glyph is scaled until it touches the border
+	test.That(t, ink.Dy() >= 50 || ink.Dx() >= 50, test.ShouldBeTrue)
+	test.That(t, countPixels(img, func(c color.RGBA) bool { return c == tint }), test.ShouldBeGreaterThan, 0)
+}
+
+func TestIconFont(t *testing.T) {
+	cps, err := ParseCodepoints(strings.NewReader("home e88a\n\nsettings 0xE8B8\n"))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cps, test.ShouldResemble, map[string]rune{"home": 0xe88a, "settings": 0xe8b8})
+	_, err = ParseCodepoints(strings.NewReader("home zz\n"))
+	test.That(t, err, test.ShouldNotBeNil)
+
+	f := &IconFont{Font: DefaultFontName, Codepoints: cps}
+	icon, err := f.Icon("settings")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, icon, test.ShouldResemble, GlyphIcon{Font: DefaultFontName, Rune: 0xe8b8})
+	_, err = f.Icon("missing")
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestRenderIconButtonScales(t *testing.T) {
+	icon, err := ParseSVG(strings.NewReader(`<svg viewBox="0 0 1 1"><rect width="1" height="1"/></svg>`))
+	test.That(t, err, test.ShouldBeNil)
+	btn := IconButton{Icon: icon, Tint: color.White, BgColor: color.Black, Padding: 6, Label: "Mute"}
+
+	small := RenderIconButton(72, btn)
+	large := RenderIconButton(120, btn)
+	white := color.RGBA{255, 255, 255, 255}
+	iconBounds := func(img *image.RGBA) image.Rectangle {
+		// the icon is the white square at the top
+		b := img.Bounds()
+		return inkBounds(img.SubImage(image.Rect(0, 0, b.Dx(), b.Dy()/2)).(*image.RGBA), black).Intersect(b)
+	}
+
+	s, l := iconBounds(small), iconBounds(large)
+	test.That(t, s.Min.Y, test.ShouldEqual, 6)
+	test.That(t, l.Min.Y, test.ShouldEqual, 10)
+	test.That(t, small.RGBAAt(36, 20), test.ShouldResemble, white)
+	test.That(t, large.RGBAAt(60, 33), test.ShouldResemble, white)
+
+	// the label is below the icon, within the padding
+	labelInk := inkBounds(small.SubImage(image.Rect(0, 60, 72, 72)).(*image.RGBA), black)
+	test.That(t, labelInk.Empty(), test.ShouldBeFalse)
+	test.That(t, labelInk.Max.Y, test.ShouldBeLessThanOrEqualTo, 66)
+}
+
+func TestWriteIcon(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+
+	err := sd.WriteIcon(2, IconButton{Icon: GlyphIcon{Rune: 'A'}, Label: "A"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, writtenKeys(ft), test.ShouldResemble, map[int]bool{2: true})
+	test.That(t, sd.WriteIcon(99, IconButton{}), test.ShouldNotBeNil)
+}
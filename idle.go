@@ -0,0 +1,260 @@
+package streamdeck
+
+import (
+	"image"
+	"sync"
+	"time"
+)
+
+// IdleConfig configures the idle behaviour of a Stream Deck. Without
+// input for DimAfter, the brightness is lowered to DimBrightness. Without
+// input for SleepAfter, the keys are blanked or show the Screensaver.
+// The next input restores the brightness and the key images.
+type IdleConfig struct {
+	// DimAfter is the time without input after which the brightness is
+	// lowered. Zero disables dimming.
+	DimAfter time.Duration
+	// DimBrightness is the brightness (0 -> 100) while dimmed.
+	DimBrightness uint16
+	// SleepAfter is the time without input after which the keys are
+	// blanked or show the Screensaver. Zero disables sleeping.
+	SleepAfter time.Duration
+	// Screensaver is shown across the whole panel while sleeping. If nil,
+	// the keys are blanked.
+	Screensaver image.Image
+	// SwallowWakeUp discards the events of the input which wakes up the
+	// Stream Deck (including the release of a wake-up key press), so that
+	// it doesn't trigger an action.
+	SwallowWakeUp bool
+	// Clock is used for the timeouts. If nil, the system clock is used.
+	Clock Clock
+}
+
+type idleState int
+
+const (
+	idleActive idleState = iota
+	idleDimmed
+	idleAsleep
+)
+
+// idleManager keeps track of the time since the last input.
+type idleManager struct {
+	lock         sync.Mutex
+	config       IdleConfig
+	state        idleState
+	gen          int // incremented with every input, invalidates running timers
+	timers       []Timer
+	swallowKeys  map[int]bool
+	swallowDials map[int]bool
+}
+
+// SetIdleConfig enables the idle behaviour described by the IdleConfig.
+// A config with neither DimAfter nor SleepAfter disables it. If the Stream
+// Deck is currently idle, it is woken up first.
+func (sd *StreamDeck) SetIdleConfig(c IdleConfig) {
+	if c.Clock == nil {
+		c.Clock = realClock{}
+	}
+
+	sd.lock.Lock()
+	old := sd.idle
+	sd.idle = nil
+	sd.lock.Unlock()
+
+	if old != nil && old.stop() {
+		sd.wake()
+	}
+
+	if c.DimAfter <= 0 && c.SleepAfter <= 0 {
+		return
+	}
+
+	im := &idleManager{
+		config:       c,
+		swallowKeys:  map[int]bool{},
+		swallowDials: map[int]bool{},
+	}
+	im.lock.Lock()
+	im.scheduleInLock(sd)
+	im.lock.Unlock()
+
+	sd.lock.Lock()
+	sd.idle = im
+	sd.lock.Unlock()
+}
+
+// Idle returns true if the Stream Deck is currently dimmed or sleeping.
+func (sd *StreamDeck) Idle() bool {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	return sd.dimmed || sd.sleeping
+}
+
+// stop cancels the timers and returns true if the Stream Deck was idle.
+func (im *idleManager) stop() bool {
+	im.lock.Lock()
+	defer im.lock.Unlock()
+	for _, t := range im.timers {
+		t.Stop()
+	}
+	im.timers = nil
+	im.gen++
+	return im.state != idleActive
+}
+
+func (im *idleManager) scheduleInLock(sd *StreamDeck) {
+	for _, t := range im.timers {
+		t.Stop()
+	}
+	im.timers = nil
+	im.gen++
+	gen := im.gen
+
+	if im.config.DimAfter > 0 {
+		im.timers = append(im.timers, im.config.Clock.AfterFunc(im.config.DimAfter, func() {
+			im.enter(sd, gen, idleDimmed)
+		}))
+	}
+	if im.config.SleepAfter > 0 {
+		im.timers = append(im.timers, im.config.Clock.AfterFunc(im.config.SleepAfter, func() {
+			im.enter(sd, gen, idleAsleep)
+		}))
+	}
+}
+
+// enter is executed by the timers. The lock is held while dimming or
+// sleeping, so that an input can't wake up the Stream Deck in between.
+func (im *idleManager) enter(sd *StreamDeck, gen int, state idleState) {
+	im.lock.Lock()
+	defer im.lock.Unlock()
+	if im.gen != gen || im.state >= state {
+		return
+	}
+	im.state = state
+
+	switch state {
+	case idleDimmed:
+		sd.dim(im.config.DimBrightness)
+	case idleAsleep:
+		sd.sleep(im.config.Screensaver)
+	}
+}
+
+// input is called by the read loop for the events of every input report.
+// It wakes up the Stream Deck if necessary and returns the events which
+// should be published.
+func (im *idleManager) input(sd *StreamDeck, events []Event) []Event {
+	if len(events) == 0 {
+		return events
+	}
+
+	im.lock.Lock()
+	wakeUp := im.state != idleActive
+	im.state = idleActive
+	im.scheduleInLock(sd)
+
+	filtered := []Event{}
+	for _, e := range events {
+		switch {
+		case e.Kind == EventKeyReleased && im.swallowKeys[e.Which]:
+			delete(im.swallowKeys, e.Which)
+		case e.Kind == EventDialReleased && im.swallowDials[e.Which]:
+			delete(im.swallowDials, e.Which)
+		case wakeUp && im.config.SwallowWakeUp:
+			if e.Kind == EventKeyPressed {
+				im.swallowKeys[e.Which] = true
+			}
+			if e.Kind == EventDialPressed {
+				im.swallowDials[e.Which] = true
+			}
+		default:
+			filtered = append(filtered, e)
+		}
+	}
+	// wake up before releasing the lock, see enter
+	if wakeUp {
+		sd.wake()
+	}
+	im.lock.Unlock()
+
+	return filtered
+}
+
+// dim lowers the brightness without changing the brightness set by the user.
+func (sd *StreamDeck) dim(b uint16) {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+
+	sd.dimmed = true
+	if !sd.connected {
+		return
+	}
+	if err := sd.sendBrightnessInLock(b); err != nil {
+		debug("dimming failed: %v", err)
+	}
+}
+
+// sleep shows the screensaver (or blanks the keys) without changing the
+// key images set by the user.
+func (sd *StreamDeck) sleep(screensaver image.Image) {
+	if screensaver == nil {
+		screensaver = image.NewRGBA(image.Rect(0, 0, sd.Config.PanelWidth(), sd.Config.PanelHeight()))
+	}
+	tiles := sd.panelTiles(screensaver, PanelOptions{})
+
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+
+	sd.sleeping = true
+	if !sd.connected {
+		return
+	}
+	for i, tile := range tiles {
+		imgBuf, err := sd.encodeImage(tile)
+		if err != nil {
+			debug("encoding screensaver failed: %v", err)
+			return
+		}
+		if err := sd.sendImageInLock(i, imgBuf); err != nil {
+			debug("showing screensaver failed: %v", err)
+			return
+		}
+	}
+}
+
+// wake restores the brightness and the key images. If no brightness has
+// been set by the user, full brightness is restored.
+func (sd *StreamDeck) wake() {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+
+	wasSleeping := sd.sleeping
+	sd.dimmed = false
+	sd.sleeping = false
+	if !sd.connected {
+		return
+	}
+
+	b := uint16(100)
+	if sd.brightness != nil {
+		b = *sd.brightness
+	}
+	if err := sd.sendBrightnessInLock(b); err != nil {
+		debug("restoring brightness failed: %v", err)
+	}
+
+	if !wasSleeping {
+		return
+	}
+	for i := 0; i < sd.Config.NumButtons(); i++ {
+		imgBuf, ok := sd.keyImages[i]
+		if !ok {
+			imgBuf, _ = sd.encodeImage(image.NewRGBA(image.Rect(0, 0, sd.Config.ButtonSize, sd.Config.ButtonSize)))
+		}
+		if err := sd.sendImageInLock(i, imgBuf); err != nil {
+			debug("restoring key %d failed: %v", i, err)
+			return
+		}
+	}
+}
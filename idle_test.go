@@ -0,0 +1,127 @@
+package streamdeck
+
+import (
+	"context"
+	"image/color"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+)
+
+func TestIdleDimSleepWake(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+	clock := newFakeClock()
+
+	test.That(t, sd.SetBrightness(80), test.ShouldBeNil)
+	test.That(t, sd.FillImage(0, solidImage(72, color.RGBA{255, 0, 0, 255})), test.ShouldBeNil)
+	ft.Reset()
+
+	sd.SetIdleConfig(IdleConfig{
+		DimAfter:      10 * time.Second,
+		DimBrightness: 5,
+		SleepAfter:    time.Minute,
+		SwallowWakeUp: true,
+		Clock:         clock,
+	})
+
+	clock.Advance(10 * time.Second)
+	test.That(t, sd.Idle(), test.ShouldBeTrue)
+	test.That(t, ft.SentFeatureReports(), test.ShouldResemble, [][]byte{{0x03, 0x08, 5, 0}})
+	test.That(t, ft.Written(), test.ShouldBeEmpty)
+
+	// while idle, the brightness is only stored
+	test.That(t, sd.SetBrightness(90), test.ShouldBeNil)
+	test.That(t, len(ft.SentFeatureReports()), test.ShouldEqual, 1)
+
+	// only key 0 isn't already black
+	clock.Advance(50 * time.Second)
+	test.That(t, writtenKeys(ft), test.ShouldResemble, map[int]bool{0: true})
+	ft.Reset()
+
+	// while sleeping, images are only stored
+	test.That(t, sd.FillImage(1, solidImage(72, color.RGBA{0, 255, 0, 255})), test.ShouldBeNil)
+	test.That(t, ft.Written(), test.ShouldBeEmpty)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := sd.Events(ctx)
+
+	// the wake up press and its release are swallowed
+	ft.Inject(keyReport(Original2, 3))
+	ft.Inject(keyReport(Original2))
+	ft.Inject(keyReport(Original2, 4))
+
+	e := <-events
+	test.That(t, e.Event.String(), test.ShouldEqual, "key-pressed:4")
+	test.That(t, sd.Idle(), test.ShouldBeFalse)
+	test.That(t, ft.SentFeatureReports(), test.ShouldResemble, [][]byte{{0x03, 0x08, 90, 0}})
+	test.That(t, writtenKeys(ft), test.ShouldResemble, map[int]bool{0: true, 1: true})
+}
+
+func TestIdleWakeWithoutSwallow(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+	clock := newFakeClock()
+
+	sd.SetIdleConfig(IdleConfig{DimAfter: time.Second, DimBrightness: 10, Clock: clock})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := sd.Events(ctx)
+
+	clock.Advance(time.Second)
+	test.That(t, sd.Idle(), test.ShouldBeTrue)
+
+	ft.Inject(keyReport(Original2, 2))
+	e := <-events
+	test.That(t, e.Event.String(), test.ShouldEqual, "key-pressed:2")
+	test.That(t, sd.Idle(), test.ShouldBeFalse)
+
+	// without a brightness set by the user, full brightness is restored;
+	// dimming alone doesn't touch the key images
+	test.That(t, ft.SentFeatureReports(), test.ShouldResemble, [][]byte{{0x03, 0x08, 10, 0}, {0x03, 0x08, 100, 0}})
+	test.That(t, ft.Written(), test.ShouldBeEmpty)
+
+	// input restarts the timeout
+	clock.Advance(500 * time.Millisecond)
+	test.That(t, sd.Idle(), test.ShouldBeFalse)
+	clock.Advance(500 * time.Millisecond)
+	test.That(t, sd.Idle(), test.ShouldBeTrue)
+
+	// disabling wakes up the Stream Deck
+	sd.SetIdleConfig(IdleConfig{})
+	test.That(t, sd.Idle(), test.ShouldBeFalse)
+}
+
+func TestIdleInputDuringDim(t *testing.T) {
+	sd, _ := newFakeStreamDeck(t, Original2)
+	sd.SetIdleConfig(IdleConfig{DimAfter: time.Second, DimBrightness: 10, Clock: newFakeClock()})
+	im := sd.idle
+	im.lock.Lock()
+	gen := im.gen
+	im.lock.Unlock()
+
+	// the timer fires, but dimming is delayed until the input arrived
+	sd.lock.Lock()
+	entered := make(chan struct{})
+	go func() {
+		im.enter(sd, gen, idleDimmed)
+		close(entered)
+	}()
+	time.Sleep(5 * time.Millisecond)
+	input := make(chan struct{})
+	go func() {
+		im.input(sd, []Event{{Kind: EventKeyPressed, Which: 1}})
+		close(input)
+	}()
+	time.Sleep(5 * time.Millisecond)
+	sd.lock.Unlock()
+	<-entered
+	<-input
+
+	// the input wins, the Stream Deck isn't left dimmed
+	test.That(t, sd.Idle(), test.ShouldBeFalse)
+	im.lock.Lock()
+	test.That(t, im.state, test.ShouldEqual, idleActive)
+	im.lock.Unlock()
+}
@@ -0,0 +1,102 @@
+package streamdeck
+
+import (
+	"bytes"
+	"strings"
+)
+
+// FirmwareRevisions holds the versions of the different firmware parts
+// of a Stream Deck. Parts which are not available on a model are empty.
+type FirmwareRevisions struct {
+	LD  string // LD is the bootloader firmware.
+	AP2 string // AP2 is the application firmware.
+}
+
+// FirmwareVersion returns the version of the application firmware as
+// reported by the device.
+func (sd *StreamDeck) FirmwareVersion() (string, error) {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+
+	if !sd.connected {
+		return "", ErrDisconnected
+	}
+	return readFirmwareVersion(sd.Config, sd.device)
+}
+
+// FirmwareRevisions returns the versions of the LD and AP2 firmware where
+// available. The first generation of Stream Decks (Original, Mini) only
+// reports the application firmware, which is returned as AP2.
+func (sd *StreamDeck) FirmwareRevisions() (FirmwareRevisions, error) {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+
+	if !sd.connected {
+		return FirmwareRevisions{}, ErrDisconnected
+	}
+
+	var fr FirmwareRevisions
+	var err error
+	fr.AP2, err = readFirmwareVersion(sd.Config, sd.device)
+	if err != nil {
+		return fr, err
+	}
+	if r, ok := sd.Config.ldFirmwareReport(); ok {
+		fr.LD, err = readFeatureString(sd.device, r)
+	}
+	return fr, err
+}
+
+// DeviceSerial returns the serial number as reported by the device itself
+// rather than by the USB descriptor (see Serial).
+func (sd *StreamDeck) DeviceSerial() (string, error) {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+
+	if !sd.connected {
+		return "", ErrDisconnected
+	}
+	return readFeatureString(sd.device, sd.Config.serialReport())
+}
+
+// Reset resets the device, which then shows the Elgato logo. The key
+// images which would be restored after a reconnect are discarded.
+func (sd *StreamDeck) Reset() error {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+
+	if !sd.connected {
+		return ErrDisconnected
+	}
+	if _, err := sd.device.SendFeatureReport(sd.Config.resetReport()); err != nil {
+		return err
+	}
+	sd.keyImages = map[int][]byte{}
+	sd.shown = map[int][]byte{}
+	return nil
+}
+
+// readFirmwareVersion reads the version of the application firmware of
+// the device through a feature report.
+func readFirmwareVersion(c *Config, t Transport) (string, error) {
+	return readFeatureString(t, c.firmwareReport())
+}
+
+// readFeatureString requests the feature report and returns the zero
+// terminated string it contains.
+func readFeatureString(t Transport, r featureReport) (string, error) {
+	buf := make([]byte, r.length)
+	buf[0] = r.id
+	n, err := t.GetFeatureReport(buf)
+	if err != nil {
+		return "", err
+	}
+	if n <= r.offset {
+		return "", nil
+	}
+	data := buf[r.offset:n]
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		data = data[:i]
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,56 @@
+package streamdeck
+
+import (
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestDeviceInfo(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Plus)
+
+	ft.SetFeatureReport(append([]byte{0x05, 0x0C, 0xFE, 0x91, 0x8A, 0x01}, "1.01.016\x00"...))
+	ft.SetFeatureReport(append([]byte{0x04, 0x0C, 0xFE, 0x91, 0x8A, 0x01}, "0.00.008\x00"...))
+	ft.SetFeatureReport(append([]byte{0x06, 0x0C}, "A00WA1234567\x00"...))
+
+	fw, err := sd.FirmwareVersion()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, fw, test.ShouldEqual, "1.01.016")
+
+	fr, err := sd.FirmwareRevisions()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, fr, test.ShouldResemble, FirmwareRevisions{LD: "0.00.008", AP2: "1.01.016"})
+
+	serial, err := sd.DeviceSerial()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, serial, test.ShouldEqual, "A00WA1234567")
+
+	test.That(t, sd.Reset(), test.ShouldBeNil)
+	reset := make([]byte, 32)
+	copy(reset, []byte{0x03, 0x02})
+	test.That(t, ft.SentFeatureReports(), test.ShouldResemble, [][]byte{reset})
+}
+
+func TestDeviceInfoLegacy(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Mini)
+
+	ft.SetFeatureReport(append([]byte{0x04, 0x55, 0xAA, 0xD4, 0x04}, "2.01.001\x00"...))
+	ft.SetFeatureReport(append([]byte{0x03, 0x55, 0xAA, 0xD3, 0x03}, "BL12K1A01234"...))
+
+	fr, err := sd.FirmwareRevisions()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, fr, test.ShouldResemble, FirmwareRevisions{AP2: "2.01.001"})
+
+	serial, err := sd.DeviceSerial()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, serial, test.ShouldEqual, "BL12K1A01234")
+
+	test.That(t, sd.Reset(), test.ShouldBeNil)
+	reset := make([]byte, 17)
+	copy(reset, []byte{0x0B, 0x63})
+	test.That(t, ft.SentFeatureReports(), test.ShouldResemble, [][]byte{reset})
+
+	ft.Close()
+	_, err = sd.FirmwareVersion()
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -0,0 +1,269 @@
+package streamdeck
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ManagerConfig configures a Manager.
+type ManagerConfig struct {
+	// Serials restricts the Manager to the Stream Decks with these serial
+	// numbers. If empty, all connected Stream Decks are managed.
+	Serials []string
+	// Aliases maps user assigned names to serial numbers.
+	Aliases map[string]string
+	// PollInterval is the interval in which the Manager looks for newly
+	// connected and removed Stream Decks. Defaults to 2 seconds.
+	PollInterval time.Duration
+}
+
+// DeviceEvent is a StateEvent tagged with the serial number of the
+// Stream Deck it originates from.
+type DeviceEvent struct {
+	Serial string
+	StateEvent
+}
+
+// Manager owns several Stream Decks at once. It opens all connected (or
+// the configured) Stream Decks, picks up newly connected ones and
+// multiplexes the events of all devices into one stream. Stream Decks
+// which are disconnected and no longer listed by the operating system are
+// closed and removed (EventDeviceRemoved), as well as those which give up
+// reconnecting (see ReconnectPolicy.MaxAttempts).
+type Manager struct {
+	lock    sync.Mutex
+	decks   map[string]*StreamDeck
+	aliases map[string]string
+	serials map[string]bool
+
+	hub  *hub[DeviceEvent]
+	list func() []Descriptor
+	open func(Descriptor) (*StreamDeck, error)
+
+	waitGroup sync.WaitGroup
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+// NewManager opens all connected Stream Decks matching the ManagerConfig
+// and starts watching for newly connected and removed devices. The devices
+// are listed without opening them, so Descriptor.Firmware is not read.
+func NewManager(mc ManagerConfig) (*Manager, error) {
+	return newManager(mc, listConnected, Open)
+}
+
+func newManager(mc ManagerConfig, list func() []Descriptor,
+	open func(Descriptor) (*StreamDeck, error)) (*Manager, error) {
+
+	if mc.PollInterval <= 0 {
+		mc.PollInterval = 2 * time.Second
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Manager{
+		decks:   map[string]*StreamDeck{},
+		aliases: map[string]string{},
+		serials: map[string]bool{},
+		hub:     newHub[DeviceEvent](),
+		list:    list,
+		open:    open,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	for alias, serial := range mc.Aliases {
+		m.aliases[alias] = serial
+	}
+	for _, serial := range mc.Serials {
+		m.serials[serial] = true
+	}
+
+	m.scan()
+
+	m.waitGroup.Add(1)
+	go m.poll(mc.PollInterval)
+
+	return m, nil
+}
+
+// Events returns a channel on which the events of all managed Stream
+// Decks are delivered. The channel is closed when ctx is cancelled or
+// the Manager is closed.
+func (m *Manager) Events(ctx context.Context) <-chan DeviceEvent {
+	return m.Subscribe(ctx, SubscribeOptions{})
+}
+
+// Subscribe is like Events, but allows to configure the buffer size and
+// the DropPolicy of the subscription.
+func (m *Manager) Subscribe(ctx context.Context, opts SubscribeOptions) <-chan DeviceEvent {
+	return m.hub.subscribe(ctx, opts)
+}
+
+// Get returns the Stream Deck with the given serial number or alias.
+func (m *Manager) Get(serialOrAlias string) (*StreamDeck, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if serial, ok := m.aliases[serialOrAlias]; ok {
+		serialOrAlias = serial
+	}
+	sd, ok := m.decks[serialOrAlias]
+	return sd, ok
+}
+
+// SetAlias assigns an alias to the Stream Deck with the given serial number.
+func (m *Manager) SetAlias(alias, serial string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.aliases[alias] = serial
+}
+
+// Serials returns the sorted serial numbers of all managed Stream Decks.
+func (m *Manager) Serials() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	serials := make([]string, 0, len(m.decks))
+	for serial := range m.decks {
+		serials = append(serials, serial)
+	}
+	sort.Strings(serials)
+	return serials
+}
+
+// Close closes all managed Stream Decks and stops watching for new ones.
+func (m *Manager) Close() error {
+	// take the decks before cancelling, so that forward doesn't report
+	// them as removed
+	m.lock.Lock()
+	decks := m.decks
+	m.decks = map[string]*StreamDeck{}
+	m.cancel()
+	m.lock.Unlock()
+
+	var firstErr error
+	for serial, sd := range decks {
+		if err := sd.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("closing %s: %w", serial, err)
+		}
+	}
+
+	m.waitGroup.Wait()
+	m.hub.close()
+
+	return firstErr
+}
+
+// poll looks periodically for newly connected and removed Stream Decks.
+func (m *Manager) poll(interval time.Duration) {
+	defer m.waitGroup.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			m.scan()
+		}
+	}
+}
+
+// scan opens all connected Stream Decks which are not yet managed and
+// removes the disconnected ones which are no longer listed.
+func (m *Manager) scan() {
+	devices := m.list()
+
+	listed := map[string]bool{}
+	for _, d := range devices {
+		listed[d.Serial] = true
+	}
+	m.lock.Lock()
+	decks := make(map[string]*StreamDeck, len(m.decks))
+	for serial, sd := range m.decks {
+		decks[serial] = sd
+	}
+	m.lock.Unlock()
+	for serial, sd := range decks {
+		// a deck which is still connected is kept, even if the listing
+		// missed it
+		if !listed[serial] && !sd.Connected() {
+			m.remove(serial, sd)
+		}
+	}
+
+	for _, d := range devices {
+		m.lock.Lock()
+		_, known := m.decks[d.Serial]
+		wanted := len(m.serials) == 0 || m.serials[d.Serial]
+		m.lock.Unlock()
+		if known || !wanted {
+			continue
+		}
+
+		sd, err := m.open(d)
+		if err != nil {
+			log.Printf("unable to open StreamDeck %s: %v", d.Serial, err)
+			continue
+		}
+		m.add(sd)
+	}
+}
+
+func (m *Manager) add(sd *StreamDeck) {
+	m.lock.Lock()
+	if m.ctx.Err() != nil {
+		m.lock.Unlock()
+		sd.Close()
+		return
+	}
+	serial := sd.Serial()
+	m.decks[serial] = sd
+	events := sd.Subscribe(m.ctx, SubscribeOptions{DropPolicy: Block})
+	m.waitGroup.Add(1)
+	m.lock.Unlock()
+
+	m.hub.publish(DeviceEvent{Serial: serial, StateEvent: StateEvent{Event: Event{Kind: EventDeviceAdded}}})
+
+	go m.forward(serial, sd, events)
+}
+
+// forward tags the events of a Stream Deck with its serial number and
+// publishes them. Stream Decks which could not be reconnected are removed.
+// Once the events of a removed Stream Deck have been forwarded, forward
+// publishes EventDeviceRemoved, so that it is always the last event.
+func (m *Manager) forward(serial string, sd *StreamDeck, events <-chan StateEvent) {
+	defer m.waitGroup.Done()
+
+	for e := range events {
+		m.hub.publish(DeviceEvent{Serial: serial, StateEvent: e})
+		if e.Kind == EventReconnectFailed {
+			break
+		}
+	}
+	if m.remove(serial, sd) {
+		m.hub.publish(DeviceEvent{Serial: serial, StateEvent: StateEvent{Event: Event{Kind: EventDeviceRemoved}}})
+	}
+}
+
+// remove stops managing a Stream Deck and closes it, which ends its
+// forward go routine. It returns false if the Manager has been closed.
+func (m *Manager) remove(serial string, sd *StreamDeck) bool {
+	m.lock.Lock()
+	managed := m.decks[serial] == sd
+	if managed {
+		delete(m.decks, serial)
+	}
+	closed := m.ctx.Err() != nil
+	m.lock.Unlock()
+
+	if managed {
+		sd.Close()
+	}
+	return !closed
+}
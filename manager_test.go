@@ -0,0 +1,150 @@
+package streamdeck
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+)
+
+type fakeBus struct {
+	lock       sync.Mutex
+	devices    []Descriptor
+	transports map[string]*FakeTransport
+}
+
+func (b *fakeBus) plug(serial string, c Config) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.devices = append(b.devices, Descriptor{Model: c.Name, Config: c, Serial: serial, Path: "/dev/" + serial})
+}
+
+func (b *fakeBus) list() []Descriptor {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return append([]Descriptor(nil), b.devices...)
+}
+
+func (b *fakeBus) open(d Descriptor) (*StreamDeck, error) {
+	ft := NewFakeTransport(d.Serial)
+	b.lock.Lock()
+	b.transports[d.Serial] = ft
+	b.lock.Unlock()
+	c := d.Config
+	sd, err := NewStreamDeckWithTransport(&c, ft)
+	if err != nil {
+		return nil, err
+	}
+	// the default policy never gives up, only rediscovery is faked
+	policy := DefaultReconnectPolicy
+	policy.Open = func(serial string) (Transport, error) {
+		return nil, errors.New("unplugged")
+	}
+	sd.SetReconnectPolicy(policy)
+	return sd, nil
+}
+
+func (b *fakeBus) transport(serial string) *FakeTransport {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.transports[serial]
+}
+
+func TestManager(t *testing.T) {
+	bus := &fakeBus{transports: map[string]*FakeTransport{}}
+	bus.plug("AAA", Original2)
+	bus.plug("BBB", XL)
+
+	m, err := newManager(ManagerConfig{
+		Aliases:      map[string]string{"left": "AAA"},
+		PollInterval: time.Millisecond,
+	}, bus.list, bus.open)
+	test.That(t, err, test.ShouldBeNil)
+	defer m.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := m.Events(ctx)
+
+	test.That(t, m.Serials(), test.ShouldResemble, []string{"AAA", "BBB"})
+	sd, ok := m.Get("left")
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, sd.Serial(), test.ShouldEqual, "AAA")
+	m.SetAlias("right", "BBB")
+	sd, ok = m.Get("right")
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, sd.Config.Name, test.ShouldEqual, XL.Name)
+	_, ok = m.Get("nope")
+	test.That(t, ok, test.ShouldBeFalse)
+
+	// events are tagged with the serial number
+	bus.transport("BBB").Inject(keyReport(XL, 20))
+	e := <-events
+	test.That(t, e.Serial, test.ShouldEqual, "BBB")
+	test.That(t, e.String(), test.ShouldEqual, "key-pressed:20")
+
+	// hot-plug
+	bus.plug("CCC", Plus)
+	e = <-events
+	test.That(t, e.Serial, test.ShouldEqual, "CCC")
+	test.That(t, e.Kind, test.ShouldEqual, EventDeviceAdded)
+	test.That(t, m.Serials(), test.ShouldResemble, []string{"AAA", "BBB", "CCC"})
+
+	// unplug
+	bus.lock.Lock()
+	bus.devices = bus.devices[1:]
+	bus.lock.Unlock()
+	bus.transport("AAA").Close()
+
+	kinds := []EventKind{}
+	for len(kinds) < 2 {
+		e = <-events
+		test.That(t, e.Serial, test.ShouldEqual, "AAA")
+		kinds = append(kinds, e.Kind)
+	}
+	test.That(t, kinds, test.ShouldResemble, []EventKind{EventDisconnected, EventDeviceRemoved})
+	test.That(t, m.Serials(), test.ShouldResemble, []string{"BBB", "CCC"})
+	_, ok = m.Get("left")
+	test.That(t, ok, test.ShouldBeFalse)
+
+	// closing the Manager doesn't report the remaining decks as removed
+	test.That(t, m.Close(), test.ShouldBeNil)
+	for e := range events {
+		test.That(t, e.Kind, test.ShouldNotEqual, EventDeviceRemoved)
+	}
+}
+
+func TestManagerKeepsConnectedDecks(t *testing.T) {
+	bus := &fakeBus{transports: map[string]*FakeTransport{}}
+	bus.plug("AAA", Original2)
+
+	m, err := newManager(ManagerConfig{PollInterval: time.Millisecond}, bus.list, bus.open)
+	test.That(t, err, test.ShouldBeNil)
+	defer m.Close()
+
+	// a connected deck missing in the listing is not removed
+	bus.lock.Lock()
+	bus.devices = nil
+	bus.lock.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	test.That(t, m.Serials(), test.ShouldResemble, []string{"AAA"})
+}
+
+func TestManagerSerialFilter(t *testing.T) {
+	bus := &fakeBus{transports: map[string]*FakeTransport{}}
+	bus.plug("AAA", Original2)
+	bus.plug("BBB", XL)
+
+	m, err := newManager(ManagerConfig{Serials: []string{"BBB"}}, bus.list, bus.open)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, m.Serials(), test.ShouldResemble, []string{"BBB"})
+
+	events := m.Events(context.Background())
+	test.That(t, m.Close(), test.ShouldBeNil)
+	_, ok := <-events
+	test.That(t, ok, test.ShouldBeFalse)
+	test.That(t, m.Serials(), test.ShouldResemble, []string{})
+}
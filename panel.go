@@ -0,0 +1,141 @@
+package streamdeck
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// FitMode determines how an image is fitted into an area of a different
+// size or aspect ratio.
+type FitMode int
+
+const (
+	// FitCropCenter scales the image to cover the whole area and crops
+	// the overlapping parts evenly on both sides.
+	FitCropCenter FitMode = iota
+	// FitLetterbox scales the image to fit into the area and fills the
+	// remaining space with the background color.
+	FitLetterbox
+	// FitStretch scales the image to the size of the area, ignoring the
+	// aspect ratio.
+	FitStretch
+	// FitTile repeats the unscaled image over the whole area.
+	FitTile
+)
+
+func (m FitMode) String() string {
+	switch m {
+	case FitCropCenter:
+		return "crop-center"
+	case FitLetterbox:
+		return "letterbox"
+	case FitStretch:
+		return "stretch"
+	case FitTile:
+		return "tile"
+	default:
+		return "unknown"
+	}
+}
+
+// PanelOptions determine how an image is shown across the panel.
+type PanelOptions struct {
+	Fit FitMode
+	// Background is the color of the letterbox bars. Defaults to black.
+	Background color.Color
+	// IgnoreSpacer slices the image into adjacent key tiles, for images
+	// designed without the gaps between the keys. By default, the image
+	// spans the gaps and the parts behind them are not shown.
+	IgnoreSpacer bool
+}
+
+// panelSize returns the size of the image which is sliced into the keys.
+func (c *Config) panelSize(opts PanelOptions) (int, int) {
+	if opts.IgnoreSpacer {
+		return c.NumButtonColumns * c.ButtonSize, c.NumButtonRows * c.ButtonSize
+	}
+	return c.PanelWidth(), c.PanelHeight()
+}
+
+// panelTiles fits an image to the panel and returns the image of every key.
+func (sd *StreamDeck) panelTiles(img image.Image, opts PanelOptions) []image.Image {
+	c := sd.Config
+	width, height := c.panelSize(opts)
+	canvas := fitImage(img, width, height, opts.Fit, opts.Background)
+
+	step := c.ButtonSize + c.Spacer
+	if opts.IgnoreSpacer {
+		step = c.ButtonSize
+	}
+
+	tiles := make([]image.Image, 0, c.NumButtons())
+	for key := 0; key < c.NumButtons(); key++ {
+		pos := image.Pt(key%c.NumButtonColumns*step, key/c.NumButtonColumns*step)
+		tile := image.NewRGBA(image.Rect(0, 0, c.ButtonSize, c.ButtonSize))
+		draw.Draw(tile, tile.Bounds(), canvas, pos, draw.Src)
+		tiles = append(tiles, tile)
+	}
+	return tiles
+}
+
+// fitImage returns an image of the given size with its origin at (0,0),
+// into which img has been fitted according to the FitMode.
+func fitImage(img image.Image, width, height int, mode FitMode, bg color.Color) *image.RGBA {
+	if bg == nil {
+		bg = color.Black
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	src := img.Bounds()
+	if src.Empty() {
+		draw.Draw(canvas, canvas.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+		return canvas
+	}
+
+	switch mode {
+	case FitStretch:
+		draw.Draw(canvas, canvas.Bounds(), scaleImage(img, width, height), image.Point{}, draw.Src)
+
+	case FitTile:
+		for y := 0; y < height; y += src.Dy() {
+			for x := 0; x < width; x += src.Dx() {
+				r := image.Rect(x, y, x+src.Dx(), y+src.Dy())
+				draw.Draw(canvas, r, img, src.Min, draw.Src)
+			}
+		}
+
+	case FitLetterbox:
+		draw.Draw(canvas, canvas.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+		w, h := width, src.Dy()*width/src.Dx()
+		if h > height {
+			w, h = src.Dx()*height/src.Dy(), height
+		}
+		w, h = max(w, 1), max(h, 1)
+		r := image.Rect(0, 0, w, h).Add(image.Pt((width-w)/2, (height-h)/2))
+		draw.Draw(canvas, r, scaleImage(img, w, h), image.Point{}, draw.Over)
+
+	default: // FitCropCenter
+		w, h := width, (src.Dy()*width+src.Dx()-1)/src.Dx()
+		if h < height {
+			w, h = (src.Dx()*height+src.Dy()-1)/src.Dy(), height
+		}
+		scaled := scaleImage(img, w, h)
+		offset := image.Pt((w-width)/2, (h-height)/2)
+		draw.Draw(canvas, canvas.Bounds(), scaled, offset, draw.Src)
+	}
+	return canvas
+}
+
+// scaleImage resizes an image to the given size (if necessary). The
+// returned image has its origin at (0,0).
+func scaleImage(img image.Image, width, height int) image.Image {
+	if r := img.Bounds(); r.Dx() == width && r.Dy() == height {
+		if r.Min == (image.Point{}) {
+			return img
+		}
+		res := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(res, res.Bounds(), img, r.Min, draw.Src)
+		return res
+	}
+	return resize(img, width, height)
+}
@@ -0,0 +1,100 @@
+package streamdeck
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+)
+
+func TestFillPanelArbitraryImages(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+	width, height := Original2.PanelWidth(), Original2.PanelHeight()
+
+	paletted := image.NewPaletted(image.Rect(0, 0, width, height), palette.Plan9)
+	draw.Draw(paletted, paletted.Bounds(), image.NewUniform(color.RGBA{255, 0, 0, 255}), image.Point{}, draw.Src)
+	test.That(t, sd.FillPanel(paletted), test.ShouldBeNil)
+	test.That(t, len(writtenKeys(ft)), test.ShouldEqual, Original2.NumButtons())
+	ft.Reset()
+
+	ycbcr := image.NewYCbCr(image.Rect(0, 0, width, height), image.YCbCrSubsampleRatio420)
+	for i := range ycbcr.Y {
+		ycbcr.Y[i] = 200
+	}
+	test.That(t, sd.FillPanel(ycbcr), test.ShouldBeNil)
+	test.That(t, len(writtenKeys(ft)), test.ShouldEqual, Original2.NumButtons())
+}
+
+func TestFillPanelDisconnected(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+	sd.SetReconnectPolicy(ReconnectPolicy{Disabled: true})
+	ft.Close()
+	for sd.Connected() {
+		time.Sleep(time.Millisecond)
+	}
+
+	err := sd.FillPanel(panelImage(Original2, color.White))
+	test.That(t, errors.Is(err, ErrDisconnected), test.ShouldBeTrue)
+}
+
+func TestPanelTilesIgnoreSpacer(t *testing.T) {
+	sd, _ := newFakeStreamDeck(t, Original2)
+	c := Original2
+	size := c.ButtonSize
+
+	img := image.NewRGBA(image.Rect(0, 0, c.NumButtonColumns*size, c.NumButtonRows*size))
+	keyColor := func(key int) color.RGBA {
+		return color.RGBA{uint8(key * 10), 0, 0, 255}
+	}
+	for key := 0; key < c.NumButtons(); key++ {
+		r := image.Rect(0, 0, size, size).Add(image.Pt(key%c.NumButtonColumns*size, key/c.NumButtonColumns*size))
+		draw.Draw(img, r, image.NewUniform(keyColor(key)), image.Point{}, draw.Src)
+	}
+
+	tiles := sd.panelTiles(img, PanelOptions{Fit: FitStretch, IgnoreSpacer: true})
+	test.That(t, len(tiles), test.ShouldEqual, c.NumButtons())
+	for key, tile := range tiles {
+		test.That(t, tile.At(0, 0), test.ShouldResemble, keyColor(key))
+		test.That(t, tile.At(size-1, size-1), test.ShouldResemble, keyColor(key))
+	}
+}
+
+func TestFitImage(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	green := color.RGBA{0, 255, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+
+	// left half red, right half green
+	halves := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	draw.Draw(halves, image.Rect(0, 0, 2, 2), image.NewUniform(red), image.Point{}, draw.Src)
+	draw.Draw(halves, image.Rect(2, 0, 4, 2), image.NewUniform(green), image.Point{}, draw.Src)
+
+	cropped := fitImage(halves, 2, 2, FitCropCenter, nil)
+	test.That(t, cropped.At(0, 0), test.ShouldResemble, red)
+	test.That(t, cropped.At(1, 1), test.ShouldResemble, green)
+
+	solid := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	draw.Draw(solid, solid.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+	boxed := fitImage(solid, 40, 40, FitLetterbox, blue)
+	test.That(t, boxed.At(20, 0), test.ShouldResemble, blue)
+	test.That(t, boxed.At(20, 20), test.ShouldResemble, red)
+	test.That(t, boxed.At(20, 39), test.ShouldResemble, blue)
+
+	// the source doesn't need to start at the origin
+	sub := halves.SubImage(image.Rect(1, 0, 3, 2))
+	stretched := fitImage(sub, 2, 2, FitStretch, nil)
+	test.That(t, stretched.At(0, 0), test.ShouldResemble, red)
+	test.That(t, stretched.At(1, 0), test.ShouldResemble, green)
+
+	tiled := fitImage(sub, 5, 3, FitTile, nil)
+	test.That(t, tiled.At(2, 0), test.ShouldResemble, red)
+	test.That(t, tiled.At(3, 2), test.ShouldResemble, green)
+	test.That(t, tiled.At(4, 2), test.ShouldResemble, red)
+
+	test.That(t, FitLetterbox.String(), test.ShouldEqual, "letterbox")
+}
@@ -0,0 +1,165 @@
+package streamdeck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bearsh/hid"
+)
+
+// ErrDisconnected is returned when the Stream Deck is currently disconnected.
+// Key images and the brightness are nevertheless stored and will be applied
+// once the device has been reconnected.
+var ErrDisconnected = errors.New("stream deck disconnected")
+
+// ReconnectPolicy determines how a StreamDeck behaves once the connection
+// to the device has been lost.
+type ReconnectPolicy struct {
+	// Disabled turns off reconnecting. The StreamDeck stops reading
+	// events once the device has been disconnected.
+	Disabled bool
+	// InitialBackoff is the time to wait before the first reconnect attempt.
+	// The backoff is doubled after each failed attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff is the upper limit of the backoff between two attempts.
+	MaxBackoff time.Duration
+	// MaxAttempts is the number of reconnect attempts before giving up.
+	// If zero, the StreamDeck never gives up.
+	MaxAttempts int
+	// Open opens the device with the given serial number. If nil, the
+	// device is rediscovered via hid.Enumerate using the ProductID of
+	// the StreamDeck's Config.
+	Open func(serial string) (Transport, error)
+}
+
+// DefaultReconnectPolicy is the ReconnectPolicy of newly created StreamDecks.
+var DefaultReconnectPolicy = ReconnectPolicy{
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+}
+
+// SetReconnectPolicy sets the policy applied when the device gets
+// disconnected. Zero durations are replaced by the values of the
+// DefaultReconnectPolicy.
+func (sd *StreamDeck) SetReconnectPolicy(p ReconnectPolicy) {
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultReconnectPolicy.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultReconnectPolicy.MaxBackoff
+	}
+
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	sd.reconnect = p
+}
+
+// Connected returns true if the device is currently connected.
+func (sd *StreamDeck) Connected() bool {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	return sd.connected
+}
+
+// reconnectDevice is executed by the read loop once the device has been
+// disconnected. It returns true if the device has been reconnected and
+// false if the read loop should terminate.
+func (sd *StreamDeck) reconnectDevice(ctx context.Context, s *State) bool {
+	sd.lock.Lock()
+	sd.connected = false
+	sd.device.Close()
+	policy := sd.reconnect
+	sd.lock.Unlock()
+
+	sd.hub.publish(StateEvent{Event: Event{Kind: EventDisconnected}, State: s.Snapshot()})
+
+	if policy.Disabled {
+		return false
+	}
+
+	open := policy.Open
+	if open == nil {
+		open = func(serial string) (Transport, error) {
+			return openHIDTransport(sd.Config, serial)
+		}
+	}
+
+	backoff := policy.InitialBackoff
+	for attempt := 1; policy.MaxAttempts == 0 || attempt <= policy.MaxAttempts; attempt++ {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, policy.MaxBackoff)
+
+		t, err := open(sd.serial)
+		if err != nil {
+			debug("reconnect attempt %d to %s failed: %v", attempt, sd.serial, err)
+			continue
+		}
+
+		sd.lock.Lock()
+		if ctx.Err() != nil {
+			sd.lock.Unlock()
+			t.Close()
+			return false
+		}
+		sd.device = t
+		sd.connected = true
+		sd.shown = map[int][]byte{}
+		if err := sd.restoreInLock(); err != nil {
+			debug("restoring %s failed: %v", sd.serial, err)
+		}
+		sd.lock.Unlock()
+
+		log.Printf("StreamDeck %s reconnected", sd.serial)
+		sd.hub.publish(StateEvent{Event: Event{Kind: EventReconnected}, State: s.Snapshot()})
+		return true
+	}
+
+	log.Printf("giving up to reconnect StreamDeck %s", sd.serial)
+	sd.hub.publish(StateEvent{Event: Event{Kind: EventReconnectFailed}, State: s.Snapshot()})
+	return false
+}
+
+// restoreInLock applies the last brightness and replays the last image of
+// every key. While idle, this is left to the wake up.
+func (sd *StreamDeck) restoreInLock() error {
+	if sd.dimmed || sd.sleeping {
+		return nil
+	}
+	if sd.brightness != nil {
+		if err := sd.sendBrightnessInLock(*sd.brightness); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < sd.Config.NumButtons(); i++ {
+		imgBuf, ok := sd.keyImages[i]
+		if !ok {
+			continue
+		}
+		if err := sd.sendImageInLock(i, imgBuf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openHIDTransport opens the HID device with the given serial number.
+func openHIDTransport(c *Config, serial string) (Transport, error) {
+	for _, d := range hid.Enumerate(VendorID, c.ProductID) {
+		if d.Serial != serial {
+			continue
+		}
+		device, err := d.Open()
+		if err != nil {
+			return nil, err
+		}
+		return newHIDTransport(device), nil
+	}
+	return nil, fmt.Errorf("no stream deck device found with serial number %s", serial)
+}
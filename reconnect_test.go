@@ -0,0 +1,97 @@
+package streamdeck
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+)
+
+func TestReconnect(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+
+	ft2 := NewFakeTransport("FAKE0001")
+	serials := make(chan string, 1)
+	sd.SetReconnectPolicy(ReconnectPolicy{
+		InitialBackoff: time.Millisecond,
+		Open: func(serial string) (Transport, error) {
+			serials <- serial
+			return ft2, nil
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := sd.Events(ctx)
+
+	test.That(t, sd.SetBrightness(70), test.ShouldBeNil)
+	img := solidImage(Original2.ButtonSize, color.RGBA{255, 0, 0, 255})
+	test.That(t, sd.FillImage(2, img), test.ShouldBeNil)
+	expected := ft.Written()
+
+	// unplug the device
+	ft.Close()
+
+	test.That(t, (<-events).Kind, test.ShouldEqual, EventDisconnected)
+	test.That(t, (<-events).Kind, test.ShouldEqual, EventReconnected)
+	test.That(t, <-serials, test.ShouldEqual, "FAKE0001")
+	test.That(t, sd.Connected(), test.ShouldBeTrue)
+
+	test.That(t, ft2.SentFeatureReports(), test.ShouldResemble, [][]byte{{0x03, 0x08, 70, 0}})
+	key2 := [][]byte{}
+	for _, r := range ft2.Written() {
+		if r[2] == 2 {
+			key2 = append(key2, r)
+		}
+	}
+	test.That(t, key2, test.ShouldResemble, expected)
+
+	// events are read from the new device
+	ft2.Inject(keyReport(Original2, 5))
+	test.That(t, (<-events).String(), test.ShouldEqual, "key-pressed:5")
+}
+
+func TestReconnectGiveUp(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+
+	attempts := 0
+	sd.SetReconnectPolicy(ReconnectPolicy{
+		InitialBackoff: time.Millisecond,
+		MaxAttempts:    3,
+		Open: func(serial string) (Transport, error) {
+			attempts++
+			return nil, errors.New("not found")
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := sd.Events(ctx)
+
+	ft.Close()
+
+	test.That(t, (<-events).Kind, test.ShouldEqual, EventDisconnected)
+	test.That(t, (<-events).Kind, test.ShouldEqual, EventReconnectFailed)
+	test.That(t, attempts, test.ShouldEqual, 3)
+	test.That(t, sd.Connected(), test.ShouldBeFalse)
+
+	img := solidImage(Original2.ButtonSize, color.RGBA{0, 0, 255, 255})
+	test.That(t, sd.FillImage(0, img), test.ShouldEqual, ErrDisconnected)
+	test.That(t, sd.SetBrightness(10), test.ShouldEqual, ErrDisconnected)
+}
+
+func TestReconnectDisabled(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+	sd.SetReconnectPolicy(ReconnectPolicy{Disabled: true})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := sd.Events(ctx)
+
+	ft.Close()
+	test.That(t, (<-events).Kind, test.ShouldEqual, EventDisconnected)
+	test.That(t, sd.Close(), test.ShouldBeNil)
+}
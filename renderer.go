@@ -0,0 +1,199 @@
+package streamdeck
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"sync"
+	"time"
+)
+
+// ErrRendererClosed is returned when images are enqueued to a closed Renderer.
+var ErrRendererClosed = errors.New("renderer closed")
+
+// RendererConfig configures a Renderer.
+type RendererConfig struct {
+	// MaxFPS limits the number of frames per second written to the device.
+	// All keys updated since the last frame are written together. Zero
+	// disables the limit.
+	MaxFPS float64
+	// ErrorBufferSize is the buffer size of the Errors channel. Defaults
+	// to DefaultBufferSize. Errors are dropped if the buffer is full.
+	ErrorBufferSize int
+}
+
+// Renderer scales, encodes and writes key images in a background go
+// routine, so that the callers are never blocked by slow USB writes. If a
+// key is updated several times before its image has been written, only
+// the newest image is written.
+type Renderer struct {
+	sd       *StreamDeck
+	interval time.Duration
+
+	lock    sync.Mutex
+	pending map[int]image.Image
+	closed  bool
+
+	wake      chan struct{}
+	done      chan struct{}
+	errors    chan error
+	waitGroup sync.WaitGroup
+}
+
+// StartRenderer starts a Renderer for the Stream Deck. A previously started
+// Renderer is closed first. The Renderer is closed (and drained)
+// automatically when the Stream Deck is closed.
+func (sd *StreamDeck) StartRenderer(rc RendererConfig) *Renderer {
+	if rc.ErrorBufferSize <= 0 {
+		rc.ErrorBufferSize = DefaultBufferSize
+	}
+
+	r := &Renderer{
+		sd:      sd,
+		pending: map[int]image.Image{},
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		errors:  make(chan error, rc.ErrorBufferSize),
+	}
+	if rc.MaxFPS > 0 {
+		r.interval = time.Duration(float64(time.Second) / rc.MaxFPS)
+	}
+
+	sd.lock.Lock()
+	old := sd.renderer
+	sd.renderer = r
+	sd.lock.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+
+	r.waitGroup.Add(1)
+	go r.run()
+
+	return r
+}
+
+// FillImage enqueues an image for a key. An image which is still pending
+// for the key is replaced. The image is copied, so the caller may reuse it
+// right away.
+func (r *Renderer) FillImage(btnIndex int, img image.Image) error {
+	if err := r.sd.checkValidKeyIndex(btnIndex); err != nil {
+		return err
+	}
+
+	// the image is encoded later in the background
+	cp := image.NewRGBA(img.Bounds())
+	draw.Draw(cp, cp.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		return ErrRendererClosed
+	}
+	r.pending[btnIndex] = cp
+
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// FillColor enqueues a solid color for a key.
+func (r *Renderer) FillColor(btnIndex, red, green, blue int) error {
+	img, err := r.sd.colorImage(red, green, blue)
+	if err != nil {
+		return err
+	}
+	return r.FillImage(btnIndex, img)
+}
+
+// Errors returns the channel on which the errors of the background writes
+// are reported. Keys which failed to encode are reported as KeyError and
+// frames written while the Stream Deck is disconnected as ErrDisconnected.
+// The channel is closed once the Renderer has been closed.
+func (r *Renderer) Errors() <-chan error {
+	return r.errors
+}
+
+// Close writes all pending images and stops the Renderer.
+func (r *Renderer) Close() {
+	r.lock.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.done)
+	}
+	r.lock.Unlock()
+
+	r.waitGroup.Wait()
+
+	r.sd.lock.Lock()
+	if r.sd.renderer == r {
+		r.sd.renderer = nil
+	}
+	r.sd.lock.Unlock()
+}
+
+func (r *Renderer) run() {
+	defer r.waitGroup.Done()
+	defer close(r.errors)
+
+	var last time.Time
+	for {
+		select {
+		case <-r.wake:
+		case <-r.done:
+			r.frame()
+			return
+		}
+
+		if wait := r.interval - time.Since(last); r.interval > 0 && wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-r.done:
+			}
+		}
+		last = time.Now()
+		r.frame()
+	}
+}
+
+// frame writes all pending images.
+func (r *Renderer) frame() {
+	r.lock.Lock()
+	pending := r.pending
+	r.pending = map[int]image.Image{}
+	r.lock.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	images := make(map[int][]byte, len(pending))
+	for key, img := range pending {
+		if img.Bounds().Dx() != r.sd.Config.ButtonSize {
+			img = resize(img, r.sd.Config.ButtonSize, r.sd.Config.ButtonSize)
+		}
+		imgBuf, err := r.sd.encodeImage(img)
+		if err != nil {
+			r.report(&KeyError{Key: key, Err: err})
+			continue
+		}
+		images[key] = imgBuf
+	}
+
+	r.sd.stopAnimations(imageKeys(images)...)
+	for _, err := range r.sd.commitImages(images) {
+		r.report(err)
+	}
+}
+
+func (r *Renderer) report(err error) {
+	select {
+	case r.errors <- err:
+	default:
+		debug("renderer error dropped: %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package streamdeck
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+)
+
+func TestRendererCoalescesPerKey(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+
+	// with a very low frame rate, the second frame is delayed until Close
+	r := sd.StartRenderer(RendererConfig{MaxFPS: 0.001})
+	test.That(t, r.FillColor(0, 255, 0, 0), test.ShouldBeNil)
+	test.That(t, r.FillImage(99, solidImage(72, color.White)), test.ShouldNotBeNil)
+
+	// wait for the first frame
+	for len(ft.Written()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	ft.Reset()
+
+	for i := 0; i < 10; i++ {
+		test.That(t, r.FillColor(1, i*20, 0, 0), test.ShouldBeNil)
+	}
+	test.That(t, r.FillColor(2, 0, 255, 0), test.ShouldBeNil)
+	test.That(t, ft.Written(), test.ShouldBeEmpty)
+
+	r.Close()
+	test.That(t, r.FillColor(3, 0, 0, 0), test.ShouldEqual, ErrRendererClosed)
+
+	// only the newest image of key 1 has been written
+	test.That(t, len(ft.Written()), test.ShouldEqual, 2)
+	test.That(t, writtenKeys(ft), test.ShouldResemble, map[int]bool{1: true, 2: true})
+
+	_, ok := <-r.Errors()
+	test.That(t, ok, test.ShouldBeFalse)
+
+	want, err := sd.encodeImage(solidImage(72, color.RGBA{180, 0, 0, 1}))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, sd.keyImages[1], test.ShouldResemble, want)
+}
+
+func TestRendererCopiesImages(t *testing.T) {
+	sd, _ := newFakeStreamDeck(t, Original2)
+
+	// the frame is delayed until Close, while the caller reuses its buffer
+	r := sd.StartRenderer(RendererConfig{MaxFPS: 0.001})
+	test.That(t, r.FillColor(0, 0, 0, 0), test.ShouldBeNil)
+	img := solidImage(72, color.RGBA{0, 0, 255, 255})
+	test.That(t, r.FillImage(1, img), test.ShouldBeNil)
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	r.Close()
+
+	want, err := sd.encodeImage(solidImage(72, color.RGBA{0, 0, 255, 255}))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, sd.keyImages[1], test.ShouldResemble, want)
+}
+
+func TestRendererReportsErrors(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+
+	sd.SetReconnectPolicy(ReconnectPolicy{Disabled: true})
+	r := sd.StartRenderer(RendererConfig{})
+	ft.Close()
+	for sd.Connected() {
+		time.Sleep(time.Millisecond)
+	}
+
+	test.That(t, r.FillColor(0, 255, 0, 0), test.ShouldBeNil)
+	err := <-r.Errors()
+	test.That(t, errors.Is(err, ErrDisconnected), test.ShouldBeTrue)
+}
+
+func TestRendererDrainedOnClose(t *testing.T) {
+	ft := NewFakeTransport("FAKE0001")
+	sd, err := NewStreamDeckWithTransport(&Original2, ft)
+	test.That(t, err, test.ShouldBeNil)
+	ft.Reset()
+
+	r := sd.StartRenderer(RendererConfig{MaxFPS: 0.001})
+	test.That(t, r.FillColor(0, 255, 0, 0), test.ShouldBeNil)
+	for len(ft.Written()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	test.That(t, r.FillColor(5, 0, 0, 255), test.ShouldBeNil)
+
+	test.That(t, sd.Close(), test.ShouldBeNil)
+	test.That(t, writtenKeys(ft), test.ShouldResemble, map[int]bool{0: true, 5: true})
+}
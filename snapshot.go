@@ -0,0 +1,95 @@
+package streamdeck
+
+import (
+	"image"
+)
+
+// Snapshot is an immutable copy of the State at a certain point in time.
+// It is safe to be used concurrently from several go routines.
+type Snapshot struct {
+	keys     []bool
+	dialPush []bool
+	dialPos  []int
+	touchPos image.Point
+}
+
+// Snapshot returns an immutable deep copy of the State.
+func (s *State) Snapshot() Snapshot {
+	return Snapshot{
+		keys:     append([]bool(nil), s.Keys...),
+		dialPush: append([]bool(nil), s.DialPush...),
+		dialPos:  append([]int(nil), s.DialPos...),
+		touchPos: s.TouchPos,
+	}
+}
+
+// State returns a mutable deep copy of the snapshot. The dial
+// configurations are not part of the snapshot.
+func (s Snapshot) State() State {
+	return State{
+		Keys:     append([]bool(nil), s.keys...),
+		DialPush: append([]bool(nil), s.dialPush...),
+		DialPos:  append([]int(nil), s.dialPos...),
+		TouchPos: s.touchPos,
+	}
+}
+
+// IsKeyDown returns true if the key is currently pressed.
+func (s Snapshot) IsKeyDown(keyIndex int) bool {
+	return keyIndex >= 0 && keyIndex < len(s.keys) && s.keys[keyIndex]
+}
+
+// PressedKeys returns the indices of all keys which are currently pressed.
+func (s Snapshot) PressedKeys() []int {
+	pressed := []int{}
+	for i, down := range s.keys {
+		if down {
+			pressed = append(pressed, i)
+		}
+	}
+	return pressed
+}
+
+// AllDown returns true if all given keys are pressed at the same time
+// (chord). It returns false if no keys are given.
+func (s Snapshot) AllDown(keyIndices ...int) bool {
+	if len(keyIndices) == 0 {
+		return false
+	}
+	for _, k := range keyIndices {
+		if !s.IsKeyDown(k) {
+			return false
+		}
+	}
+	return true
+}
+
+// AnyDown returns true if at least one of the given keys is pressed.
+func (s Snapshot) AnyDown(keyIndices ...int) bool {
+	for _, k := range keyIndices {
+		if s.IsKeyDown(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDialDown returns true if the dial is currently pushed.
+func (s Snapshot) IsDialDown(dialIndex int) bool {
+	return dialIndex >= 0 && dialIndex < len(s.dialPush) && s.dialPush[dialIndex]
+}
+
+// DialValue returns the position of the dial, see DialConfig. If the dial
+// has not been used yet, the initial position of the DefaultDialConfig
+// is returned.
+func (s Snapshot) DialValue(dialIndex int) int {
+	if dialIndex < 0 || dialIndex >= len(s.dialPos) {
+		return DefaultDialConfig.Initial
+	}
+	return s.dialPos[dialIndex]
+}
+
+// TouchPos returns the position of the last touch on the touch strip.
+func (s Snapshot) TouchPos() image.Point {
+	return s.touchPos
+}
@@ -0,0 +1,93 @@
+package streamdeck
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestSnapshotQueries(t *testing.T) {
+	s := State{}
+	_, err := s.Update(&Plus, []byte{1, 0, 8, 0, 0, 1, 1, 0, 0, 0, 0, 1})
+	test.That(t, err, test.ShouldBeNil)
+	_, err = s.Update(&Plus, []byte{1, 3, 5, 0, 1, 0, 5, 0, 0})
+	test.That(t, err, test.ShouldBeNil)
+	_, err = s.Update(&Plus, []byte{1, 3, 5, 0, 0, 0, 0, 1, 0})
+	test.That(t, err, test.ShouldBeNil)
+
+	snap := s.Snapshot()
+	test.That(t, snap.IsKeyDown(1), test.ShouldBeTrue)
+	test.That(t, snap.IsKeyDown(0), test.ShouldBeFalse)
+	test.That(t, snap.IsKeyDown(99), test.ShouldBeFalse)
+	test.That(t, snap.PressedKeys(), test.ShouldResemble, []int{1, 2, 7})
+	test.That(t, snap.AllDown(1, 2), test.ShouldBeTrue)
+	test.That(t, snap.AllDown(1, 3), test.ShouldBeFalse)
+	test.That(t, snap.AllDown(), test.ShouldBeFalse)
+	test.That(t, snap.AnyDown(0, 7), test.ShouldBeTrue)
+	test.That(t, snap.IsDialDown(2), test.ShouldBeTrue)
+	test.That(t, snap.IsDialDown(1), test.ShouldBeFalse)
+	test.That(t, snap.DialValue(1), test.ShouldEqual, 55)
+	test.That(t, snap.DialValue(9), test.ShouldEqual, 50)
+
+	// the snapshot must not change when the State is updated
+	_, err = s.Update(&Plus, []byte{1, 0, 8, 0, 0, 0, 0, 0, 0, 0, 0, 0})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, snap.PressedKeys(), test.ShouldResemble, []int{1, 2, 7})
+
+	st := snap.State()
+	st.Keys[1] = false
+	test.That(t, snap.IsKeyDown(1), test.ShouldBeTrue)
+}
+
+// TestSnapshotRace is meant to be run with the race detector. The read
+// loop keeps updating its State while subscribers inspect their snapshots.
+func TestSnapshotRace(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const numReports = 200
+	wg := sync.WaitGroup{}
+	var mismatches atomic.Int32
+	for i := 0; i < 3; i++ {
+		events := sd.Subscribe(ctx, SubscribeOptions{BufferSize: numReports, DropPolicy: Block})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < numReports; n++ {
+				e := <-events
+				if e.State.IsKeyDown(e.Which) != (e.Kind == EventKeyPressed) {
+					mismatches.Add(1)
+				}
+				_ = e.State.PressedKeys()
+			}
+		}()
+	}
+
+	var snapshots []State
+	lock := sync.Mutex{}
+	sd.SetBtnEventCb(func(s State, e Event) {
+		lock.Lock()
+		defer lock.Unlock()
+		snapshots = append(snapshots, s)
+	})
+
+	for i := 0; i < numReports/2; i++ {
+		ft.Inject(keyReport(Original2, i%15))
+		ft.Inject(keyReport(Original2))
+	}
+	wg.Wait()
+	test.That(t, mismatches.Load(), test.ShouldEqual, 0)
+
+	lock.Lock()
+	defer lock.Unlock()
+	for i, s := range snapshots {
+		if i%2 == 0 {
+			test.That(t, s.Keys[(i/2)%15], test.ShouldBeTrue)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"image"
 	"image/jpeg"
@@ -12,7 +13,6 @@ import (
 	"sync"
 
 	"github.com/disintegration/gift"
-	"github.com/golang/freetype"
 	"github.com/golang/freetype/truetype"
 
 	"github.com/bearsh/hid"
@@ -42,30 +42,68 @@ type BtnEvent func(s State, e Event)
 
 // StreamDeck is the object representing the Elgato Stream Deck.
 type StreamDeck struct {
-	lock       sync.Mutex
-	device     *hid.Device
-	btnEventCb BtnEvent
-	Config     *Config
+	lock           sync.Mutex
+	device         Transport
+	serial         string
+	connected      bool
+	hub            *hub[StateEvent]
+	btnEventCancel context.CancelFunc
+	Config         *Config
+
+	// dial configurations which still have to be applied by the read loop
+	pendingDials map[int]DialConfig
+
+	// state of the device which is restored after a reconnect
+	keyImages  map[int][]byte
+	shown      map[int][]byte // encoded images currently shown on the device
+	brightness *uint16
+	idle       *idleManager
+	renderer   *Renderer
+	animator   *animator
+	closing    bool // Close has been called, no animations are started
+	dimmed     bool
+	sleeping   bool
+	reconnect  ReconnectPolicy
 
 	waitGroup sync.WaitGroup
 	cancel    context.CancelFunc
 }
 
 // TextButton holds the lines to be written to a button and the desired
-// Background color.
+// Background color. If Layout is set, the lines are laid out automatically.
 type TextButton struct {
 	Lines   []TextLine
 	BgColor color.Color
+	Layout  *TextLayout
 }
 
-// TextLine holds the content of one text line.
+// TextLine holds the content of one text line. PosX and PosY are the top
+// left corner of the line. The FontSize defaults to DefaultFontSize, the
+// FontColor to white.
 type TextLine struct {
-	Text      string
-	PosX      int
-	PosY      int
-	Font      *truetype.Font
+	Text string
+	PosX int
+	PosY int
+	Font *truetype.Font
+	// FontName refers to a font in DefaultFontRegistry. It is only used if
+	// Font is nil.
+	FontName  string
 	FontSize  float64
 	FontColor color.Color
+
+	// Opacity (0 -> 1) of the whole line. Zero is treated as fully opaque.
+	Opacity float64
+	// StrokeWidth (in pixel) of an outline around the glyphs in StrokeColor
+	// (defaults to black).
+	StrokeWidth int
+	StrokeColor color.Color
+	// ShadowOffset and ShadowBlur (the standard deviation of the gaussian
+	// blur) enable a drop shadow in ShadowColor (defaults to translucent black).
+	ShadowOffset image.Point
+	ShadowBlur   float32
+	ShadowColor  color.Color
+	// Backdrop draws a rounded rectangle behind the text.
+	Backdrop *TextBackdrop
 }
 
 // NewStreamDeck is the constructor of the StreamDeck object. If several StreamDecks
@@ -125,12 +163,33 @@ func NewStreamDeckWithConfig(c *Config, serial string) (*StreamDeck, error) {
 
 	log.Printf("Connected to StreamDeck: %v", devices[id])
 
-	sd := &StreamDeck{
-		device: device,
-		Config: c,
+	return NewStreamDeckWithTransport(c, newHIDTransport(device))
+}
+
+// NewStreamDeckWithTransport is the constructor for a Stream Deck which is
+// reached through the given Transport, e.g. a FakeTransport in tests. Unlike
+// the other constructors, the Config has to be supplied.
+func NewStreamDeckWithTransport(c *Config, t Transport) (*StreamDeck, error) {
+
+	if c == nil {
+		return nil, fmt.Errorf("config must not be nil")
 	}
 
-	sd.ClearAllBtns()
+	sd := &StreamDeck{
+		device:    t,
+		serial:    t.Serial(),
+		connected: true,
+		hub:       newHub[StateEvent](),
+		Config:    c,
+		keyImages: map[int][]byte{},
+		shown:     map[int][]byte{},
+		reconnect: DefaultReconnectPolicy,
+	}
+
+	if err := sd.ClearAllBtns(); err != nil {
+		t.Close()
+		return nil, err
+	}
 
 	cancelCtx, cancel := context.WithCancel(context.Background())
 	sd.cancel = cancel
@@ -141,61 +200,97 @@ func NewStreamDeckWithConfig(c *Config, serial string) (*StreamDeck, error) {
 	return sd, nil
 }
 
-// SetBtnEventCb sets the BtnEvent callback which get's executed whenever
-// a Button event (pressed/released) occures.
-func (sd *StreamDeck) SetBtnEventCb(ev BtnEvent) {
-	sd.lock.Lock()
-	defer sd.lock.Unlock()
-	sd.btnEventCb = ev
-}
-
 // Read will listen in a for loop for incoming messages from the Stream Deck.
-// It is typically executed in a dedicated go routine.
+// It is typically executed in a dedicated go routine. If the device gets
+// disconnected, it tries to reconnect according to the ReconnectPolicy.
 func (sd *StreamDeck) read(ctx context.Context) {
 	defer sd.waitGroup.Done()
 	myState := State{}
 
 	for ctx.Err() == nil {
-		data := make([]byte, 24)
+		data := make([]byte, sd.Config.inputReportLength())
 		_, err := sd.device.Read(data)
 		if err != nil {
-			fmt.Println(err)
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("StreamDeck %s disconnected: %v", sd.serial, err)
+			if !sd.reconnectDevice(ctx, &myState) {
+				return
+			}
 			continue
 		}
 
-		debug("read data:", data)
+		debug("read data: %v", data)
+
+		sd.lock.Lock()
+		for i, dc := range sd.pendingDials {
+			myState.ConfigureDial(i, dc)
+		}
+		sd.pendingDials = nil
+		idle := sd.idle
+		sd.lock.Unlock()
 
 		events, err := myState.Update(sd.Config, data)
 		if err != nil {
-			fmt.Println(err)
+			debug("%v", err)
 			continue
 		}
 
-		var cb BtnEvent
-		sd.lock.Lock()
-		cb = sd.btnEventCb
-		sd.lock.Unlock()
-		if cb != nil {
-			for _, event := range events {
-				go func() {
-					cb(myState, event)
-				}()
-			}
+		if idle != nil {
+			events = idle.input(sd, events)
+		}
+
+		for _, event := range events {
+			sd.hub.publish(StateEvent{Event: event, State: myState.Snapshot()})
 		}
 	}
 }
 
 // Close the connection to the Elgato Stream Deck
 func (sd *StreamDeck) Close() error {
+	// write the images still pending in the renderer
+	sd.lock.Lock()
+	sd.closing = true
+	renderer := sd.renderer
+	animator := sd.animator
+	sd.lock.Unlock()
+	if renderer != nil {
+		renderer.Close()
+	}
+	if animator != nil {
+		animator.stopAll()
+	}
+
 	sd.cancel()
-	err := sd.device.Close()
+
+	sd.lock.Lock()
+	device := sd.device
+	sd.lock.Unlock()
+
+	err := device.Close()
 	sd.waitGroup.Wait()
+
+	// the device might have been replaced while reconnecting
+	sd.lock.Lock()
+	if sd.device != device {
+		sd.device.Close()
+	}
+	sd.connected = false
+	if sd.btnEventCancel != nil {
+		sd.btnEventCancel()
+		sd.btnEventCancel = nil
+	}
+	sd.lock.Unlock()
+
+	sd.hub.close()
+
 	return err
 }
 
 // Serial returns the Serial number of this Elgato Stream Deck
 func (sd *StreamDeck) Serial() string {
-	return sd.device.Serial
+	return sd.serial
 }
 
 // ClearBtn fills a particular key with the color black
@@ -209,45 +304,52 @@ func (sd *StreamDeck) ClearBtn(btnIndex int) error {
 
 // ClearAllBtns fills all keys with the color black
 func (sd *StreamDeck) ClearAllBtns() error {
-	for i := sd.Config.NumButtons() - 1; i >= 0; i-- {
-		err := sd.ClearBtn(i)
-		if err != nil {
-			return err
-		}
+	img, err := sd.colorImage(0, 0, 0)
+	if err != nil {
+		return err
 	}
-	return nil
+	imgBuf, err := sd.encodeImage(img)
+	if err != nil {
+		return err
+	}
+
+	b := sd.Batch()
+	for i := 0; i < sd.Config.NumButtons(); i++ {
+		b.images[i] = imgBuf
+	}
+	return b.Flush()
 }
 
 // FillColor fills the given button with a solid color.
 func (sd *StreamDeck) FillColor(btnIndex, r, g, b int) error {
-	if err := checkRGB(r); err != nil {
+	img, err := sd.colorImage(r, g, b)
+	if err != nil {
 		return err
 	}
+	return sd.FillImage(btnIndex, img)
+}
+
+// colorImage returns a key image with a solid color.
+func (sd *StreamDeck) colorImage(r, g, b int) (image.Image, error) {
+	if err := checkRGB(r); err != nil {
+		return nil, err
+	}
 	if err := checkRGB(g); err != nil {
-		return err
+		return nil, err
 	}
 	if err := checkRGB(b); err != nil {
-		return err
+		return nil, err
 	}
 
 	img := image.NewRGBA(image.Rect(0, 0, sd.Config.ButtonSize, sd.Config.ButtonSize))
 	color := color.RGBA{uint8(r), uint8(g), uint8(b), 1}
 	draw.Draw(img, img.Bounds(), image.NewUniform(color), image.Point{0, 0}, draw.Src)
-
-	return sd.FillImage(btnIndex, img)
+	return img, nil
 }
 
 func (sd *StreamDeck) encodeImage(img image.Image) ([]byte, error) {
 
-	if sd.Config.ImageRotate {
-		newImage := image.NewRGBA(img.Bounds())
-		for x := 0; x < sd.Config.ButtonSize; x++ {
-			for y := 0; y < sd.Config.ButtonSize; y++ {
-				newImage.Set(x, y, img.At(sd.Config.ButtonSize-x, sd.Config.ButtonSize-y))
-			}
-		}
-		img = newImage
-	}
+	img = orientImage(sd.Config, img)
 
 	// the original Stream Deck only supports BMP
 	if sd.Config.ImageFormat == "bmp" {
@@ -256,34 +358,78 @@ func (sd *StreamDeck) encodeImage(img image.Image) ([]byte, error) {
 
 	// starting from Streamdeck MK1, the images have to be in JPG format
 	if sd.Config.ImageFormat == "jpg" {
-		buf := bytes.Buffer{}
-		err := jpeg.Encode(&buf, img, nil)
-		if err != nil {
-			return nil, err
-		}
-		return buf.Bytes(), nil
+		return encodeJPEG(img)
 	}
 
 	return nil, fmt.Errorf("unknown image format [%s]", sd.Config.ImageFormat)
 
 }
 
-// encode all button images as BMP
+// encodeJPEG encodes an image as JPEG with the default quality.
+func encodeJPEG(img image.Image) ([]byte, error) {
+	buf := bytes.Buffer{}
+	err := jpeg.Encode(&buf, img, nil)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// orientImage rotates and / or transposes a key image into the orientation
+// expected by the device.
+func orientImage(c *Config, img image.Image) image.Image {
+	if !c.ImageRotate && !c.ImageTranspose {
+		return img
+	}
+
+	size := c.ButtonSize
+	origin := img.Bounds().Min
+	newImage := image.NewRGBA(image.Rect(0, 0, size, size))
+	for x := 0; x < size; x++ {
+		for y := 0; y < size; y++ {
+			srcX, srcY := x, y
+			if c.ImageTranspose {
+				srcX, srcY = srcY, srcX
+			}
+			if c.ImageRotate {
+				srcX, srcY = size-1-srcX, size-1-srcY
+			}
+			newImage.Set(x, y, img.At(origin.X+srcX, origin.Y+srcY))
+		}
+	}
+	return newImage
+}
+
+// encodeBMP encodes a button image as an uncompressed 24 bit BMP
+// (bottom-up row order).
 func encodeBMP(c *Config, img image.Image) ([]byte, error) {
-	imgBuf := []byte{
-		'\x42', '\x4D', '\xF6', '\x3C', '\x00', '\x00', '\x00', '\x00',
-		'\x00', '\x00', '\x36', '\x00', '\x00', '\x00', '\x28', '\x00',
-		'\x00', '\x00', '\x48', '\x00', '\x00', '\x00', '\x48', '\x00',
-		'\x00', '\x00', '\x01', '\x00', '\x18', '\x00', '\x00', '\x00',
-		'\x00', '\x00', '\xC0', '\x3C', '\x00', '\x00', '\xC4', '\x0E',
-		'\x00', '\x00', '\xC4', '\x0E', '\x00', '\x00', '\x00', '\x00',
-		'\x00', '\x00', '\x00', '\x00', '\x00', '\x00'}
-
-	for row := 0; row < c.ButtonSize; row++ {
-		for line := c.ButtonSize - 1; line >= 0; line-- {
-			r, g, b, _ := img.At(line, row).RGBA()
-			imgBuf = append(imgBuf, byte(b), byte(g), byte(r))
+	const headerSize = 54
+
+	size := c.ButtonSize
+	rowSize := (size*3 + 3) &^ 3
+	dataSize := rowSize * size
+
+	imgBuf := make([]byte, headerSize, headerSize+dataSize)
+	imgBuf[0], imgBuf[1] = 'B', 'M'
+	binary.LittleEndian.PutUint32(imgBuf[2:], uint32(headerSize+dataSize))
+	binary.LittleEndian.PutUint32(imgBuf[10:], headerSize)
+	binary.LittleEndian.PutUint32(imgBuf[14:], 40) // size of the info header
+	binary.LittleEndian.PutUint32(imgBuf[18:], uint32(size))
+	binary.LittleEndian.PutUint32(imgBuf[22:], uint32(size))
+	binary.LittleEndian.PutUint16(imgBuf[26:], 1)  // color planes
+	binary.LittleEndian.PutUint16(imgBuf[28:], 24) // bits per pixel
+	binary.LittleEndian.PutUint32(imgBuf[34:], uint32(dataSize))
+	binary.LittleEndian.PutUint32(imgBuf[38:], 3780) // 96 dpi
+	binary.LittleEndian.PutUint32(imgBuf[42:], 3780) // 96 dpi
+
+	origin := img.Bounds().Min
+	padding := make([]byte, rowSize-size*3)
+	for row := size - 1; row >= 0; row-- {
+		for col := 0; col < size; col++ {
+			r, g, b, _ := img.At(origin.X+col, origin.Y+row).RGBA()
+			imgBuf = append(imgBuf, byte(b>>8), byte(g>>8), byte(r>>8))
 		}
+		imgBuf = append(imgBuf, padding...)
 	}
 	return imgBuf, nil
 }
@@ -307,9 +453,31 @@ func (sd *StreamDeck) FillImage(btnIndex int, img image.Image) error {
 		return err
 	}
 
+	// the image replaces an animation playing on the key
+	sd.stopAnimations(btnIndex)
+
 	sd.lock.Lock()
 	defer sd.lock.Unlock()
 
+	// remember the image so that it can be restored after a reconnect
+	sd.keyImages[btnIndex] = imgBuf
+	if !sd.connected {
+		return ErrDisconnected
+	}
+	if sd.sleeping {
+		// shown on wake up
+		return nil
+	}
+
+	return sd.sendImageInLock(btnIndex, imgBuf)
+}
+
+// writeImageInLock writes an encoded key image to the device.
+func (sd *StreamDeck) writeImageInLock(btnIndex int, imgBuf []byte) error {
+	if sd.Config.ImageFormat == "bmp" && sd.Config.PagedBMP {
+		return sd.sendPagedBMPInLock(btnIndex, imgBuf)
+	}
+
 	if sd.Config.ImageFormat == "bmp" {
 		splitPoint := 7803
 		err := sd.sendOriginalSingleMsgInLock(btnIndex, 1, imgBuf[0:splitPoint])
@@ -320,15 +488,16 @@ func (sd *StreamDeck) FillImage(btnIndex int, img image.Image) error {
 		return sd.sendOriginalSingleMsgInLock(btnIndex, 2, imgBuf[splitPoint:])
 	}
 
+	reportLength := sd.Config.imageReportLength()
 	headerSize := 8
 	bytesLeft := len(imgBuf)
 	pos := 0
 	pageNumber := uint16(0)
 
 	for bytesLeft > 0 {
-		imgToSend := min(bytesLeft, 1024-headerSize)
+		imgToSend := min(bytesLeft, reportLength-headerSize)
 
-		buf := make([]byte, 1024)
+		buf := make([]byte, reportLength)
 		bytesLeft -= imgToSend
 
 		buf[0] = 0x02
@@ -347,13 +516,9 @@ func (sd *StreamDeck) FillImage(btnIndex int, img image.Image) error {
 
 		debug("going to Write len(buf): %d imgToSend: %d bytesLeft: %d pageNumber: %d len(imgBuf): %d", len(buf), imgToSend, bytesLeft, pageNumber, len(imgBuf))
 
-		n, err := sd.device.Write(buf)
-		if err != nil {
+		if err := sd.writeInLock(buf); err != nil {
 			return err
 		}
-		if n != len(buf) {
-			return fmt.Errorf("only wrote %d of %d", n, len(buf))
-		}
 
 		pageNumber++
 		pos += imgToSend
@@ -364,7 +529,7 @@ func (sd *StreamDeck) FillImage(btnIndex int, img image.Image) error {
 }
 
 func (sd *StreamDeck) sendOriginalSingleMsgInLock(btnIndex int, pageNumber uint16, data []byte) error {
-	buf := make([]byte, 8191)
+	buf := make([]byte, sd.Config.imageReportLength())
 	buf[0] = 0x02
 	buf[1] = 0x01
 	binary.LittleEndian.PutUint16(buf[2:], pageNumber)
@@ -374,6 +539,48 @@ func (sd *StreamDeck) sendOriginalSingleMsgInLock(btnIndex int, pageNumber uint1
 	buf[5] = byte(sd.Config.fixKey(btnIndex))
 	copy(buf[16:], data)
 
+	return sd.writeInLock(buf)
+}
+
+// sendPagedBMPInLock sends a BMP image in pages, each starting with a
+// 16 byte header (Stream Deck Mini).
+func (sd *StreamDeck) sendPagedBMPInLock(btnIndex int, imgBuf []byte) error {
+	reportLength := sd.Config.imageReportLength()
+	headerSize := 16
+	bytesLeft := len(imgBuf)
+	pos := 0
+	pageNumber := uint16(0)
+
+	for bytesLeft > 0 {
+		imgToSend := min(bytesLeft, reportLength-headerSize)
+
+		buf := make([]byte, reportLength)
+		bytesLeft -= imgToSend
+
+		buf[0] = 0x02
+		buf[1] = 0x01
+		binary.LittleEndian.PutUint16(buf[2:], pageNumber)
+		if bytesLeft == 0 {
+			buf[4] = 1
+		}
+		buf[5] = byte(btnIndex + 1)
+
+		copy(buf[headerSize:], imgBuf[pos:(pos+imgToSend)])
+
+		if err := sd.writeInLock(buf); err != nil {
+			return err
+		}
+
+		pageNumber++
+		pos += imgToSend
+	}
+
+	return nil
+}
+
+// writeInLock writes an output report to the device and ensures that it
+// has been written completely.
+func (sd *StreamDeck) writeInLock(buf []byte) error {
 	n, err := sd.device.Write(buf)
 	if err != nil {
 		return err
@@ -384,7 +591,8 @@ func (sd *StreamDeck) sendOriginalSingleMsgInLock(btnIndex int, pageNumber uint1
 	return nil
 }
 
-// FillImageFromFile fills the given key with an image from a file.
+// FillImageFromFile fills the given key with an image from a file. Of
+// animated GIFs only the first frame is shown, see PlayGIFFromFile.
 func (sd *StreamDeck) FillImageFromFile(keyIndex int, path string) error {
 	reader, err := os.Open(path)
 	if err != nil {
@@ -400,48 +608,37 @@ func (sd *StreamDeck) FillImageFromFile(keyIndex int, path string) error {
 	return sd.FillImage(keyIndex, img)
 }
 
-// FillPanel fills the whole panel witn an image. The image is scaled to fit
-// and then center-cropped (if necessary). The native picture size is 360px x 216px.
+// FillPanel fills the whole panel with an image. The image is scaled to
+// cover the panel and then center-cropped (if necessary). The areas between
+// the keys are not shown. See FillPanelWithOptions for other fit modes.
 func (sd *StreamDeck) FillPanel(img image.Image) error {
+	return sd.FillPanelWithOptions(img, PanelOptions{})
+}
 
-	// resize if the picture width is larger or smaller than panel
-	rect := img.Bounds()
-	if rect.Dx() != sd.Config.PanelWidth() {
-		newWidthRatio := float32(rect.Dx()) / float32((sd.Config.PanelWidth()))
-		img = resize(img, sd.Config.PanelWidth(), int(float32(rect.Dy())/newWidthRatio))
-	}
-
-	// if the Canvas is larger than sd.Config.PanelWidth() x sd.Config.PanelHeight() then we crop
-	// the Center match sd.Config.PanelWidth() x sd.Config.PanelHeight()
-	rect = img.Bounds()
-	if rect.Dx() > sd.Config.PanelWidth() || rect.Dy() > sd.Config.PanelHeight() {
-		img = cropCenter(img, sd.Config.PanelWidth(), sd.Config.PanelHeight())
-	}
-
-	counter := 0
-
-	for row := 0; row < sd.Config.NumButtonRows; row++ {
-		for col := 0; col < sd.Config.NumButtonColumns; col++ {
-			rect := image.Rectangle{
-				Min: image.Point{
-					X: col*sd.Config.ButtonSize + col*sd.Config.Spacer,
-					Y: row*sd.Config.ButtonSize + row*sd.Config.Spacer,
-				},
-				Max: image.Point{
-					X: col*sd.Config.ButtonSize + col*sd.Config.Spacer + sd.Config.ButtonSize - 1,
-					Y: row*sd.Config.ButtonSize + row*sd.Config.Spacer + sd.Config.ButtonSize - 1,
-				},
-			}
-			sub := img.(*image.RGBA).SubImage(rect)
-			sd.FillImage(counter, sub)
-			counter++
+// FillPanelWithOptions fills the whole panel with an image, fitted and
+// sliced according to the PanelOptions. The images of all keys are written
+// even if some of them fail; the failed keys are reported as KeyError.
+func (sd *StreamDeck) FillPanelWithOptions(img image.Image, opts PanelOptions) error {
+	images := map[int][]byte{}
+	var errs []error
+	for key, tile := range sd.panelTiles(img, opts) {
+		imgBuf, err := sd.encodeImage(tile)
+		if err != nil {
+			errs = append(errs, &KeyError{Key: key, Err: err})
+			continue
 		}
+		images[key] = imgBuf
 	}
-
-	return nil
+	sd.stopAnimations(imageKeys(images)...)
+	errs = append(errs, sd.commitImages(images)...)
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
 }
 
-// FillPanelFromFile fills the entire panel with an image from a file.
+// FillPanelFromFile fills the entire panel with an image from a file, see
+// FillPanel.
 func (sd *StreamDeck) FillPanelFromFile(path string) error {
 	reader, err := os.Open(path)
 	if err != nil {
@@ -457,8 +654,8 @@ func (sd *StreamDeck) FillPanelFromFile(path string) error {
 	return sd.FillPanel(img)
 }
 
-// WriteText can write several lines of Text to a button. It is up to the
-// user to ensure that the lines fit properly on the button.
+// WriteText can write several lines of Text to a button. Without a Layout,
+// it is up to the user to ensure that the lines fit properly on the button.
 func (sd *StreamDeck) WriteText(btnIndex int, textBtn TextButton) error {
 
 	if err := sd.checkValidKeyIndex(btnIndex); err != nil {
@@ -470,39 +667,27 @@ func (sd *StreamDeck) WriteText(btnIndex int, textBtn TextButton) error {
 	// fill button with Background color
 	draw.Draw(img, img.Bounds(), bg, image.Point{0, 0}, draw.Src)
 
-	return sd.WriteTextOnImage(btnIndex, img, textBtn.Lines)
+	return sd.WriteTextOnImageWithLayout(btnIndex, img, textBtn.Lines, textBtn.Layout)
 }
 
-// WriteText can write several lines of Text to a button. It is up to the
-// user to ensure that the lines fit properly on the button.
+// WriteTextOnImage writes several lines of Text on top of an image at
+// the lines' positions. It is up to the user to ensure that the lines fit
+// properly on the button.
 func (sd *StreamDeck) WriteTextOnImage(btnIndex int, imgIn image.Image, lines []TextLine) error {
-	img := resize(imgIn, sd.Config.ButtonSize, sd.Config.ButtonSize)
-
-	for _, line := range lines {
-		if line.Font == nil {
-			line.Font = MonoRegular
-		}
-		fontColor := image.NewUniform(line.FontColor)
-		c := freetype.NewContext()
-		c.SetDPI(72)
-		c.SetFont(line.Font)
-		c.SetFontSize(line.FontSize)
-		c.SetClip(img.Bounds())
-		c.SetDst(img)
-		c.SetSrc(fontColor)
-		pt := freetype.Pt(line.PosX, line.PosY+int(c.PointToFixed(24)>>6))
-
-		if _, err := c.DrawString(line.Text, pt); err != nil {
-			return err
-		}
-	}
+	return sd.WriteTextOnImageWithLayout(btnIndex, imgIn, lines, nil)
+}
 
+// WriteTextOnImageWithLayout writes several lines of Text on top of an
+// image. If layout is nil, the lines are placed at their positions.
+func (sd *StreamDeck) WriteTextOnImageWithLayout(btnIndex int, imgIn image.Image, lines []TextLine, layout *TextLayout) error {
+	img := resize(imgIn, sd.Config.ButtonSize, sd.Config.ButtonSize)
+	DrawText(img, img.Bounds(), lines, layout)
 	return sd.FillImage(btnIndex, img)
 }
 
 // checkValidKeyIndex checks that the keyIndex is valid
 func (sd *StreamDeck) checkValidKeyIndex(keyIndex int) error {
-	if keyIndex < 0 || keyIndex > sd.Config.NumButtons() {
+	if keyIndex < 0 || keyIndex >= sd.Config.NumButtons() {
 		return fmt.Errorf("invalid key index")
 	}
 	return nil
@@ -510,9 +695,29 @@ func (sd *StreamDeck) checkValidKeyIndex(keyIndex int) error {
 
 // b 0 -> 100
 func (sd *StreamDeck) SetBrightness(b uint16) error {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+
+	sd.brightness = &b
+	if !sd.connected {
+		return ErrDisconnected
+	}
+	if sd.dimmed || sd.sleeping {
+		// applied on wake up
+		return nil
+	}
+	return sd.sendBrightnessInLock(b)
+}
 
-	buf := []byte{0x03, 0x08, 0xFF, 0xFF}
-	binary.LittleEndian.PutUint16(buf[2:], b)
+func (sd *StreamDeck) sendBrightnessInLock(b uint16) error {
+	var buf []byte
+	if sd.Config.legacyReports() {
+		buf = make([]byte, 17)
+		copy(buf, []byte{0x05, 0x55, 0xAA, 0xD1, 0x01, byte(b)})
+	} else {
+		buf = []byte{0x03, 0x08, 0xFF, 0xFF}
+		binary.LittleEndian.PutUint16(buf[2:], b)
+	}
 
 	_, err := sd.device.SendFeatureReport(buf)
 	return err
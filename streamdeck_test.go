@@ -0,0 +1,241 @@
+package streamdeck
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func newFakeStreamDeck(t *testing.T, c Config) (*StreamDeck, *FakeTransport) {
+	t.Helper()
+	ft := NewFakeTransport("FAKE0001")
+	sd, err := NewStreamDeckWithTransport(&c, ft)
+	test.That(t, err, test.ShouldBeNil)
+	t.Cleanup(func() { sd.Close() })
+	ft.Reset()
+	return sd, ft
+}
+
+func solidImage(size int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{0, 0}, draw.Src)
+	return img
+}
+
+// writtenKeys returns the keys addressed by the image reports written so far.
+func writtenKeys(ft *FakeTransport) map[int]bool {
+	keys := map[int]bool{}
+	for _, r := range ft.Written() {
+		keys[int(r[2])] = true
+	}
+	return keys
+}
+
+func TestNewStreamDeckWithTransportClearsKeys(t *testing.T) {
+	ft := NewFakeTransport("FAKE0001")
+	sd, err := NewStreamDeckWithTransport(&Original2, ft)
+	test.That(t, err, test.ShouldBeNil)
+	defer sd.Close()
+
+	test.That(t, sd.Serial(), test.ShouldEqual, "FAKE0001")
+
+	keys := map[byte]bool{}
+	for _, r := range ft.Written() {
+		keys[r[2]] = true
+	}
+	test.That(t, len(keys), test.ShouldEqual, Original2.NumButtons())
+}
+
+func TestFillImageJPEGPages(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+
+	img := solidImage(Original2.ButtonSize, color.RGBA{255, 0, 0, 255})
+	err := sd.FillImage(3, img)
+	test.That(t, err, test.ShouldBeNil)
+
+	expected, err := sd.encodeImage(img)
+	test.That(t, err, test.ShouldBeNil)
+
+	reports := ft.Written()
+	test.That(t, len(reports), test.ShouldEqual, (len(expected)+1015)/1016)
+
+	payload := []byte{}
+	for i, r := range reports {
+		test.That(t, len(r), test.ShouldEqual, 1024)
+		test.That(t, r[0], test.ShouldEqual, 0x02)
+		test.That(t, r[1], test.ShouldEqual, 0x07)
+		test.That(t, r[2], test.ShouldEqual, 3)
+		if i == len(reports)-1 {
+			test.That(t, r[3], test.ShouldEqual, 1)
+		} else {
+			test.That(t, r[3], test.ShouldEqual, 0)
+		}
+		test.That(t, binary.LittleEndian.Uint16(r[6:]), test.ShouldEqual, i)
+		n := int(binary.LittleEndian.Uint16(r[4:]))
+		payload = append(payload, r[8:8+n]...)
+	}
+	test.That(t, payload, test.ShouldResemble, expected)
+}
+
+func TestFillImageReportLength(t *testing.T) {
+	c := XL
+	c.ImageReportLength = 512
+	sd, ft := newFakeStreamDeck(t, c)
+
+	img := solidImage(XL.ButtonSize, color.RGBA{255, 255, 0, 255})
+	err := sd.FillImage(31, img)
+	test.That(t, err, test.ShouldBeNil)
+
+	expected, err := sd.encodeImage(img)
+	test.That(t, err, test.ShouldBeNil)
+
+	reports := ft.Written()
+	test.That(t, len(reports), test.ShouldEqual, (len(expected)+503)/504)
+	for _, r := range reports {
+		test.That(t, len(r), test.ShouldEqual, 512)
+		test.That(t, r[2], test.ShouldEqual, 31)
+	}
+}
+
+func TestFillImageBMPPages(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original)
+
+	img := solidImage(Original.ButtonSize, color.RGBA{0, 0, 255, 255})
+	err := sd.FillImage(0, img)
+	test.That(t, err, test.ShouldBeNil)
+
+	expected, err := sd.encodeImage(img)
+	test.That(t, err, test.ShouldBeNil)
+
+	reports := ft.Written()
+	test.That(t, len(reports), test.ShouldEqual, 2)
+
+	for i, r := range reports {
+		test.That(t, len(r), test.ShouldEqual, 8191)
+		test.That(t, r[0], test.ShouldEqual, 0x02)
+		test.That(t, r[1], test.ShouldEqual, 0x01)
+		test.That(t, binary.LittleEndian.Uint16(r[2:]), test.ShouldEqual, i+1)
+		test.That(t, r[5], test.ShouldEqual, Original.fixKey(0))
+	}
+	test.That(t, reports[0][4], test.ShouldEqual, 0)
+	test.That(t, reports[1][4], test.ShouldEqual, 1)
+
+	test.That(t, reports[0][16:16+7803], test.ShouldResemble, expected[:7803])
+	test.That(t, reports[1][16:16+len(expected)-7803], test.ShouldResemble, expected[7803:])
+}
+
+// originalBMPHeader is the BMP header sent to the 15 key Original.
+var originalBMPHeader = []byte{
+	0x42, 0x4D, 0xF6, 0x3C, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x36, 0x00,
+	0x00, 0x00, 0x28, 0x00, 0x00, 0x00, 0x48, 0x00, 0x00, 0x00, 0x48, 0x00,
+	0x00, 0x00, 0x01, 0x00, 0x18, 0x00, 0x00, 0x00, 0x00, 0x00, 0xC0, 0x3C,
+	0x00, 0x00, 0xC4, 0x0E, 0x00, 0x00, 0xC4, 0x0E, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+}
+
+func TestOriginalWireFormat(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original)
+
+	img := solidImage(Original.ButtonSize, color.RGBA{0, 0, 0, 255})
+	img.Set(10, 1, color.RGBA{255, 0, 0, 255})
+	test.That(t, sd.FillImage(0, img), test.ShouldBeNil)
+
+	reports := ft.Written()
+	test.That(t, len(reports), test.ShouldEqual, 2)
+	size := len(originalBMPHeader) + 72*72*3
+	payload := append(append([]byte{}, reports[0][16:16+7803]...), reports[1][16:16+size-7803]...)
+	test.That(t, payload[:54], test.ShouldResemble, originalBMPHeader)
+
+	// the image is rotated by 180° and sent bottom-up, so the rows arrive
+	// top-down and each from right to left (BGR), like before
+	pixels := payload[54:]
+	red := (1*72 + 72 - 1 - 10) * 3
+	test.That(t, pixels[red:red+3], test.ShouldResemble, []byte{0, 0, 255})
+	test.That(t, bytes.Count(pixels, []byte{0}), test.ShouldEqual, len(pixels)-1)
+
+	test.That(t, sd.SetBrightness(42), test.ShouldBeNil)
+	brightness := make([]byte, 17)
+	copy(brightness, []byte{0x05, 0x55, 0xAA, 0xD1, 0x01, 42})
+	test.That(t, ft.SentFeatureReports(), test.ShouldResemble, [][]byte{brightness})
+}
+
+func TestSetBrightness(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+
+	err := sd.SetBrightness(80)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ft.SentFeatureReports(), test.ShouldResemble, [][]byte{{0x03, 0x08, 80, 0}})
+}
+
+func TestReadLoopDeliversEvents(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+
+	events := make(chan Event, 2)
+	sd.SetBtnEventCb(func(s State, e Event) {
+		events <- e
+	})
+
+	report := make([]byte, 24)
+	report[0] = 1
+	report[4+2] = 1
+	ft.Inject(report)
+
+	e := <-events
+	test.That(t, e.String(), test.ShouldEqual, "key-pressed:2")
+}
+
+func TestFillImageMiniPages(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Mini)
+
+	img := solidImage(Mini.ButtonSize, color.RGBA{0, 255, 0, 255})
+	err := sd.FillImage(4, img)
+	test.That(t, err, test.ShouldBeNil)
+
+	expected, err := sd.encodeImage(img)
+	test.That(t, err, test.ShouldBeNil)
+
+	reports := ft.Written()
+	test.That(t, len(reports), test.ShouldEqual, (len(expected)+1007)/1008)
+
+	payload := []byte{}
+	for i, r := range reports {
+		test.That(t, len(r), test.ShouldEqual, 1024)
+		test.That(t, r[0], test.ShouldEqual, 0x02)
+		test.That(t, r[1], test.ShouldEqual, 0x01)
+		test.That(t, binary.LittleEndian.Uint16(r[2:]), test.ShouldEqual, i)
+		test.That(t, r[5], test.ShouldEqual, 5)
+		if i == len(reports)-1 {
+			test.That(t, r[4], test.ShouldEqual, 1)
+			payload = append(payload, r[16:16+len(expected)-len(payload)]...)
+		} else {
+			test.That(t, r[4], test.ShouldEqual, 0)
+			payload = append(payload, r[16:]...)
+		}
+	}
+	test.That(t, payload, test.ShouldResemble, expected)
+}
+
+func TestSetBrightnessLegacy(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Mini)
+
+	err := sd.SetBrightness(42)
+	test.That(t, err, test.ShouldBeNil)
+
+	expected := make([]byte, 17)
+	copy(expected, []byte{0x05, 0x55, 0xAA, 0xD1, 0x01, 42})
+	test.That(t, ft.SentFeatureReports(), test.ShouldResemble, [][]byte{expected})
+}
+
+func TestFillImageInvalidKey(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, XL)
+
+	img := solidImage(XL.ButtonSize, color.RGBA{255, 255, 255, 255})
+	test.That(t, sd.FillImage(32, img), test.ShouldNotBeNil)
+	test.That(t, sd.FillImage(-1, img), test.ShouldNotBeNil)
+	test.That(t, len(ft.Written()), test.ShouldEqual, 0)
+}
@@ -0,0 +1,261 @@
+package streamdeck
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"sync"
+	"time"
+)
+
+// fpsWindow is the number of frames over which the FPS are measured.
+const fpsWindow = 30
+
+// StreamStats are the statistics of a PanelStreamer.
+type StreamStats struct {
+	Frames       int     // number of frames written
+	TilesSent    int     // number of key images written to the device
+	TilesSkipped int     // number of key images already shown on the keys
+	FPS          float64 // achieved frames per second over the last frames
+}
+
+// PanelStreamer shows a sequence of frames (e.g. a camera preview or a
+// video clip) across the whole panel. Each frame is sliced into key tiles
+// like FillPanel does, but only the tiles which differ from what the keys
+// currently show are sent to the device.
+type PanelStreamer struct {
+	sd    *StreamDeck
+	clock Clock
+
+	lock  sync.Mutex
+	opts  PanelOptions
+	times []time.Time // times of the last frames
+	stats StreamStats
+}
+
+// NewPanelStreamer returns a PanelStreamer for the Stream Deck.
+func (sd *StreamDeck) NewPanelStreamer() *PanelStreamer {
+	return &PanelStreamer{
+		sd:    sd,
+		clock: realClock{},
+	}
+}
+
+// WriteFrame shows a frame across the panel. Tiles which could not be
+// encoded are reported as KeyError, the other tiles are written anyway.
+func (ps *PanelStreamer) WriteFrame(img image.Image) error {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	tiles := ps.sd.panelTiles(img, ps.opts)
+
+	var errs []error
+	images := map[int][]byte{}
+	for key, tile := range tiles {
+		imgBuf, err := ps.sd.encodeImage(tile)
+		if err != nil {
+			errs = append(errs, &KeyError{Key: key, Err: err})
+			continue
+		}
+		images[key] = imgBuf
+	}
+
+	ps.sd.stopAnimations(imageKeys(images)...)
+	sent, skipped, commitErrs := ps.sd.commitImagesCounted(images)
+	errs = append(errs, commitErrs...)
+	ps.stats.TilesSent += sent
+	ps.stats.TilesSkipped += skipped
+	ps.stats.Frames++
+
+	ps.times = append(ps.times, ps.clock.Now())
+	if len(ps.times) > fpsWindow {
+		ps.times = ps.times[1:]
+	}
+	if n := len(ps.times); n > 1 {
+		if d := ps.times[n-1].Sub(ps.times[0]); d > 0 {
+			ps.stats.FPS = float64(n-1) / d.Seconds()
+		}
+	}
+
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+// SetPanelOptions sets how the frames are fitted to the panel. By default,
+// they are scaled and center-cropped.
+func (ps *PanelStreamer) SetPanelOptions(opts PanelOptions) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	ps.opts = opts
+}
+
+// Stats returns the statistics of the PanelStreamer.
+func (ps *PanelStreamer) Stats() StreamStats {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	return ps.stats
+}
+
+// FPS returns the achieved frames per second over the last frames.
+func (ps *PanelStreamer) FPS() float64 {
+	return ps.Stats().FPS
+}
+
+// Stream shows all frames received on the channel until the channel is
+// closed, ctx is cancelled or a frame could not be written.
+func (ps *PanelStreamer) Stream(ctx context.Context, frames <-chan image.Image) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case img, ok := <-frames:
+			if !ok {
+				return nil
+			}
+			if err := ps.WriteFrame(img); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// StreamMJPEG shows the frames of an MJPEG stream, either concatenated
+// JPEG images or a multipart stream (the part headers are skipped), until
+// the end of the stream, ctx is cancelled or a frame could not be written.
+func (ps *PanelStreamer) StreamMJPEG(ctx context.Context, r io.Reader) error {
+	br := bufio.NewReader(r)
+	for ctx.Err() == nil {
+		data, err := readJPEG(br)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		if err := ps.WriteFrame(img); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// readJPEG reads the next JPEG image from a stream. Everything before the
+// start of image marker is skipped. The segments are parsed so that
+// embedded thumbnails don't end the image prematurely.
+func readJPEG(br *bufio.Reader) ([]byte, error) {
+	prev := byte(0)
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if prev == 0xFF && b == 0xD8 {
+			break
+		}
+		prev = b
+	}
+
+	buf := []byte{0xFF, 0xD8}
+	marker, err := nextJPEGMarker(br)
+	for {
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		buf = append(buf, 0xFF, marker)
+
+		switch {
+		case marker == 0xD9: // end of image
+			return buf, nil
+		case marker >= 0xD0 && marker <= 0xD7, marker == 0x01: // no payload
+			marker, err = nextJPEGMarker(br)
+			continue
+		}
+
+		length := make([]byte, 2)
+		if _, err := io.ReadFull(br, length); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		n := int(binary.BigEndian.Uint16(length))
+		if n < 2 {
+			return nil, fmt.Errorf("invalid jpeg segment length %d", n)
+		}
+		segment := make([]byte, n-2)
+		if _, err := io.ReadFull(br, segment); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		buf = append(buf, length...)
+		buf = append(buf, segment...)
+
+		if marker != 0xDA {
+			marker, err = nextJPEGMarker(br)
+			continue
+		}
+
+		// start of scan: the entropy coded data runs up to the next marker
+		marker, buf, err = scanJPEGEntropy(br, buf)
+	}
+}
+
+// nextJPEGMarker reads the next marker (skipping fill bytes).
+func nextJPEGMarker(br *bufio.Reader) (byte, error) {
+	b, err := br.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b != 0xFF {
+		return 0, fmt.Errorf("invalid jpeg marker 0x%x", b)
+	}
+	for {
+		b, err := br.ReadByte()
+		if err != nil || b != 0xFF {
+			return b, err
+		}
+	}
+}
+
+// scanJPEGEntropy appends entropy coded data (including stuffed bytes and
+// restart markers) to buf and returns the marker following it.
+func scanJPEGEntropy(br *bufio.Reader, buf []byte) (byte, []byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, buf, err
+		}
+		if b != 0xFF {
+			buf = append(buf, b)
+			continue
+		}
+		m, err := br.ReadByte()
+		for err == nil && m == 0xFF {
+			m, err = br.ReadByte()
+		}
+		if err != nil {
+			return 0, buf, err
+		}
+		if m == 0x00 || (m >= 0xD0 && m <= 0xD7) {
+			buf = append(buf, 0xFF, m)
+			continue
+		}
+		return m, buf, nil
+	}
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,139 @@
+package streamdeck
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+)
+
+func panelImage(c Config, col color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, c.PanelWidth(), c.PanelHeight()))
+	draw.Draw(img, img.Bounds(), image.NewUniform(col), image.Point{}, draw.Src)
+	return img
+}
+
+// markKey paints a small square into the center of a key.
+func markKey(c Config, img *image.RGBA, key int, col color.Color) {
+	x := (key%c.NumButtonColumns)*(c.ButtonSize+c.Spacer) + c.ButtonSize/2
+	y := (key/c.NumButtonColumns)*(c.ButtonSize+c.Spacer) + c.ButtonSize/2
+	draw.Draw(img, image.Rect(x-8, y-8, x+8, y+8), image.NewUniform(col), image.Point{}, draw.Src)
+}
+
+func TestPanelStreamerSendsChangedTiles(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+	clock := newFakeClock()
+	ps := sd.NewPanelStreamer()
+	ps.clock = clock
+
+	frame := panelImage(Original2, color.RGBA{255, 0, 0, 255})
+	test.That(t, ps.WriteFrame(frame), test.ShouldBeNil)
+	test.That(t, len(ft.Written()), test.ShouldEqual, Original2.NumButtons())
+	ft.Reset()
+
+	clock.Advance(100 * time.Millisecond)
+	test.That(t, ps.WriteFrame(frame), test.ShouldBeNil)
+	test.That(t, ft.Written(), test.ShouldBeEmpty)
+
+	next := panelImage(Original2, color.RGBA{255, 0, 0, 255})
+	markKey(Original2, next, 7, color.White)
+	clock.Advance(100 * time.Millisecond)
+	test.That(t, ps.WriteFrame(next), test.ShouldBeNil)
+	test.That(t, writtenKeys(ft), test.ShouldResemble, map[int]bool{7: true})
+
+	stats := ps.Stats()
+	test.That(t, stats.Frames, test.ShouldEqual, 3)
+	test.That(t, stats.TilesSent, test.ShouldEqual, Original2.NumButtons()+1)
+	test.That(t, stats.TilesSkipped, test.ShouldEqual, 2*Original2.NumButtons()-1)
+	test.That(t, ps.FPS(), test.ShouldAlmostEqual, 10)
+}
+
+func TestPanelStreamerRestoresOverwrittenTiles(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+	ps := sd.NewPanelStreamer()
+
+	frame := panelImage(Original2, color.RGBA{255, 0, 0, 255})
+	test.That(t, ps.WriteFrame(frame), test.ShouldBeNil)
+
+	// a key overwritten in between is sent again with the same frame
+	test.That(t, sd.FillColor(4, 0, 0, 255), test.ShouldBeNil)
+	ft.Reset()
+	test.That(t, ps.WriteFrame(frame), test.ShouldBeNil)
+	test.That(t, writtenKeys(ft), test.ShouldResemble, map[int]bool{4: true})
+	test.That(t, ps.Stats().TilesSent, test.ShouldEqual, Original2.NumButtons()+1)
+}
+
+func TestPanelStreamerStatsCountWrittenTiles(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+	clock := newFakeClock()
+	ps := sd.NewPanelStreamer()
+
+	// while sleeping, the tiles are only stored
+	sd.SetIdleConfig(IdleConfig{SleepAfter: time.Minute, Clock: clock})
+	clock.Advance(time.Minute)
+	ft.Reset()
+	test.That(t, ps.WriteFrame(panelImage(Original2, color.RGBA{255, 0, 0, 255})), test.ShouldBeNil)
+	test.That(t, ft.Written(), test.ShouldBeEmpty)
+	test.That(t, ps.Stats().TilesSent, test.ShouldEqual, 0)
+	test.That(t, ps.Stats().TilesSkipped, test.ShouldEqual, 0)
+
+	// while disconnected, nothing is sent either
+	sd.SetReconnectPolicy(ReconnectPolicy{Disabled: true})
+	ft.Close()
+	for sd.Connected() {
+		time.Sleep(time.Millisecond)
+	}
+	err := ps.WriteFrame(panelImage(Original2, color.RGBA{0, 255, 0, 255}))
+	test.That(t, err, test.ShouldEqual, ErrDisconnected)
+	stats := ps.Stats()
+	test.That(t, stats.Frames, test.ShouldEqual, 2)
+	test.That(t, stats.TilesSent, test.ShouldEqual, 0)
+	test.That(t, stats.TilesSkipped, test.ShouldEqual, 0)
+}
+
+func TestPanelStreamerStream(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+	ps := sd.NewPanelStreamer()
+
+	frames := make(chan image.Image, 2)
+	frames <- panelImage(Original2, color.RGBA{0, 0, 255, 255})
+	frames <- panelImage(Original2, color.RGBA{0, 0, 255, 255})
+	close(frames)
+
+	test.That(t, ps.Stream(context.Background(), frames), test.ShouldBeNil)
+	test.That(t, ps.Stats().Frames, test.ShouldEqual, 2)
+	test.That(t, len(ft.Written()), test.ShouldEqual, Original2.NumButtons())
+}
+
+func TestPanelStreamerMJPEG(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+	ps := sd.NewPanelStreamer()
+
+	first := panelImage(Original2, color.RGBA{0, 128, 0, 255})
+	second := panelImage(Original2, color.RGBA{0, 128, 0, 255})
+	markKey(Original2, second, 3, color.White)
+
+	stream := bytes.Buffer{}
+	for _, img := range []image.Image{first, first, second} {
+		stream.WriteString("--frame\r\nContent-Type: image/jpeg\r\n\r\n")
+		test.That(t, jpeg.Encode(&stream, img, nil), test.ShouldBeNil)
+		stream.WriteString("\r\n")
+	}
+
+	test.That(t, ps.StreamMJPEG(context.Background(), &stream), test.ShouldBeNil)
+	test.That(t, ps.Stats().Frames, test.ShouldEqual, 3)
+	test.That(t, ps.Stats().TilesSent, test.ShouldEqual, Original2.NumButtons()+1)
+	test.That(t, len(ft.Written()), test.ShouldEqual, Original2.NumButtons()+1)
+
+	// a truncated stream is an error
+	truncated := bytes.Buffer{}
+	test.That(t, jpeg.Encode(&truncated, first, nil), test.ShouldBeNil)
+	truncated.Truncate(truncated.Len() / 2)
+	test.That(t, ps.StreamMJPEG(context.Background(), &truncated), test.ShouldNotBeNil)
+}
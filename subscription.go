@@ -0,0 +1,270 @@
+package streamdeck
+
+import (
+	"context"
+	"sync"
+)
+
+// DropPolicy determines what happens when an event is published to a
+// subscriber whose buffer is full.
+type DropPolicy int
+
+const (
+	// DropNewest discards the event which could not be delivered.
+	DropNewest DropPolicy = iota
+	// DropOldest discards the oldest buffered event to make room for the new one.
+	DropOldest
+	// Block waits until the subscriber has received the event. This stalls
+	// the delivery to all other subscribers.
+	Block
+)
+
+func (p DropPolicy) String() string {
+	switch p {
+	case DropNewest:
+		return "drop-newest"
+	case DropOldest:
+		return "drop-oldest"
+	case Block:
+		return "block"
+	default:
+		return "unknown"
+	}
+}
+
+// DefaultBufferSize is the buffer size of a subscription if none is specified.
+const DefaultBufferSize = 32
+
+// SubscribeOptions configures an event subscription.
+type SubscribeOptions struct {
+	BufferSize int // BufferSize of the channel, DefaultBufferSize if zero.
+	DropPolicy DropPolicy
+}
+
+// StateEvent is an Event together with a Snapshot of the State after the
+// event has been applied.
+type StateEvent struct {
+	Event
+	State Snapshot
+}
+
+type subscriber[T any] struct {
+	ch     chan T
+	done   chan struct{} // closed when the subscriber is removed from the hub
+	ctx    context.Context
+	policy DropPolicy
+}
+
+// hub distributes events in order to all subscribers.
+type hub[T any] struct {
+	lock   sync.Mutex
+	subs   map[*subscriber[T]]struct{}
+	closed bool
+}
+
+func newHub[T any]() *hub[T] {
+	return &hub[T]{
+		subs: map[*subscriber[T]]struct{}{},
+	}
+}
+
+func (h *hub[T]) subscribe(ctx context.Context, opts SubscribeOptions) <-chan T {
+	size := opts.BufferSize
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	sub := &subscriber[T]{
+		ch:     make(chan T, size),
+		done:   make(chan struct{}),
+		ctx:    ctx,
+		policy: opts.DropPolicy,
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.closed {
+		close(sub.ch)
+		return sub.ch
+	}
+	h.subs[sub] = struct{}{}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			h.unsubscribe(sub)
+		case <-sub.done:
+		}
+	}()
+
+	return sub.ch
+}
+
+func (h *hub[T]) unsubscribe(sub *subscriber[T]) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if _, ok := h.subs[sub]; ok {
+		h.removeInLock(sub)
+	}
+}
+
+func (h *hub[T]) removeInLock(sub *subscriber[T]) {
+	delete(h.subs, sub)
+	close(sub.ch)
+	close(sub.done)
+}
+
+// publish delivers the event to all subscribers according to their DropPolicy.
+func (h *hub[T]) publish(ev T) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for sub := range h.subs {
+		switch sub.policy {
+		case Block:
+			select {
+			case sub.ch <- ev:
+			case <-sub.ctx.Done():
+			}
+		case DropOldest:
+			for sent := false; !sent; {
+				select {
+				case sub.ch <- ev:
+					sent = true
+				default:
+					select {
+					case <-sub.ch:
+						debug("dropping oldest event for subscriber")
+					default:
+					}
+				}
+			}
+		default:
+			select {
+			case sub.ch <- ev:
+			default:
+				debug("dropping event %v for subscriber", ev)
+			}
+		}
+	}
+}
+
+// close terminates all subscriptions.
+func (h *hub[T]) close() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for sub := range h.subs {
+		h.removeInLock(sub)
+	}
+	h.closed = true
+}
+
+// Events returns a channel on which all events of the Stream Deck are
+// delivered in order. The channel is closed when ctx is cancelled or the
+// Stream Deck is closed. If the channel's buffer is full, new events are
+// dropped.
+func (sd *StreamDeck) Events(ctx context.Context) <-chan StateEvent {
+	return sd.Subscribe(ctx, SubscribeOptions{})
+}
+
+// Subscribe is like Events, but allows to configure the buffer size and
+// the DropPolicy of the subscription.
+func (sd *StreamDeck) Subscribe(ctx context.Context, opts SubscribeOptions) <-chan StateEvent {
+	return sd.hub.subscribe(ctx, opts)
+}
+
+// SetBtnEventCb sets the BtnEvent callback which get's executed whenever
+// a Button event (pressed/released) occures. The callback is executed
+// sequentially in a dedicated go routine, in the order of the events.
+// The events are queued without limit, so a slow callback never stalls the
+// Stream Deck, and the callback may use the Stream Deck (even close it).
+// A nil callback removes the current callback.
+func (sd *StreamDeck) SetBtnEventCb(ev BtnEvent) {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+
+	if sd.btnEventCancel != nil {
+		sd.btnEventCancel()
+		sd.btnEventCancel = nil
+	}
+	if ev == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sd.btnEventCancel = cancel
+	events := sd.hub.subscribe(ctx, SubscribeOptions{DropPolicy: Block})
+	queue := newEventQueue()
+
+	// the subscription is drained right away, independent of the callback
+	go func() {
+		for e := range events {
+			queue.push(e)
+		}
+		queue.close()
+	}()
+
+	go func() {
+		for {
+			e, ok := queue.pop()
+			if !ok || ctx.Err() != nil {
+				return
+			}
+			ev(e.State.State(), e.Event)
+		}
+	}()
+}
+
+// eventQueue is an unbounded FIFO of events.
+type eventQueue struct {
+	lock   sync.Mutex
+	events []StateEvent
+	closed bool
+	wake   chan struct{}
+}
+
+func newEventQueue() *eventQueue {
+	return &eventQueue{wake: make(chan struct{}, 1)}
+}
+
+func (q *eventQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *eventQueue) push(e StateEvent) {
+	q.lock.Lock()
+	q.events = append(q.events, e)
+	q.lock.Unlock()
+	q.notify()
+}
+
+// close lets pop return false once the queued events have been taken.
+func (q *eventQueue) close() {
+	q.lock.Lock()
+	q.closed = true
+	q.lock.Unlock()
+	q.notify()
+}
+
+// pop waits for the next event.
+func (q *eventQueue) pop() (StateEvent, bool) {
+	for {
+		q.lock.Lock()
+		if len(q.events) > 0 {
+			e := q.events[0]
+			q.events[0] = StateEvent{}
+			q.events = q.events[1:]
+			q.lock.Unlock()
+			return e, true
+		}
+		closed := q.closed
+		q.lock.Unlock()
+		if closed {
+			return StateEvent{}, false
+		}
+		<-q.wake
+	}
+}
@@ -0,0 +1,161 @@
+package streamdeck
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+)
+
+func keyReport(c Config, pressed ...int) []byte {
+	report := make([]byte, 4+c.NumButtons())
+	report[0] = 1
+	for _, k := range pressed {
+		report[4+k] = 1
+	}
+	return report
+}
+
+func TestEventsOrdered(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events1 := sd.Events(ctx)
+	events2 := sd.Subscribe(ctx, SubscribeOptions{BufferSize: 1, DropPolicy: Block})
+
+	for i := 0; i < 10; i++ {
+		ft.Inject(keyReport(Original2, 3))
+		ft.Inject(keyReport(Original2))
+	}
+
+	for _, events := range []<-chan StateEvent{events2, events1} {
+		for i := 0; i < 10; i++ {
+			e := <-events
+			test.That(t, e.String(), test.ShouldEqual, "key-pressed:3")
+			test.That(t, e.State.IsKeyDown(3), test.ShouldBeTrue)
+			e = <-events
+			test.That(t, e.String(), test.ShouldEqual, "key-released:3")
+			test.That(t, e.State.IsKeyDown(3), test.ShouldBeFalse)
+		}
+	}
+}
+
+func TestEventsCancel(t *testing.T) {
+	sd, _ := newFakeStreamDeck(t, Original2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := sd.Events(ctx)
+	cancel()
+
+	select {
+	case _, ok := <-events:
+		test.That(t, ok, test.ShouldBeFalse)
+	case <-time.After(time.Second):
+		t.Fatal("subscription was not closed")
+	}
+}
+
+func TestEventsClosedWithStreamDeck(t *testing.T) {
+	ft := NewFakeTransport("FAKE0001")
+	sd, err := NewStreamDeckWithTransport(&Original2, ft)
+	test.That(t, err, test.ShouldBeNil)
+
+	events := sd.Events(context.Background())
+	test.That(t, sd.Close(), test.ShouldBeNil)
+
+	_, ok := <-events
+	test.That(t, ok, test.ShouldBeFalse)
+
+	_, ok = <-sd.Events(context.Background())
+	test.That(t, ok, test.ShouldBeFalse)
+}
+
+func TestHubDropPolicies(t *testing.T) {
+	h := newHub[StateEvent]()
+	ctx := context.Background()
+
+	newest := h.subscribe(ctx, SubscribeOptions{BufferSize: 2, DropPolicy: DropNewest})
+	oldest := h.subscribe(ctx, SubscribeOptions{BufferSize: 2, DropPolicy: DropOldest})
+
+	for i := 0; i < 4; i++ {
+		h.publish(StateEvent{Event: Event{Kind: EventKeyPressed, Which: i}})
+	}
+	h.close()
+
+	which := func(ch <-chan StateEvent) []int {
+		res := []int{}
+		for e := range ch {
+			res = append(res, e.Which)
+		}
+		return res
+	}
+	test.That(t, which(newest), test.ShouldResemble, []int{0, 1})
+	test.That(t, which(oldest), test.ShouldResemble, []int{2, 3})
+}
+
+func TestBtnEventCbOrdered(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+
+	events := make(chan Event, 20)
+	sd.SetBtnEventCb(func(s State, e Event) {
+		time.Sleep(time.Millisecond)
+		events <- e
+	})
+
+	for i := 0; i < 5; i++ {
+		ft.Inject(keyReport(Original2, 1))
+		ft.Inject(keyReport(Original2))
+	}
+
+	for i := 0; i < 5; i++ {
+		test.That(t, (<-events).Kind, test.ShouldEqual, EventKeyPressed)
+		test.That(t, (<-events).Kind, test.ShouldEqual, EventKeyReleased)
+	}
+}
+
+func TestBtnEventCbDoesNotStall(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+
+	release := make(chan struct{})
+	calls := make(chan Event, 200)
+	sd.SetBtnEventCb(func(s State, e Event) {
+		<-release
+		calls <- e
+	})
+	events := sd.Subscribe(context.Background(), SubscribeOptions{BufferSize: 200})
+
+	// the blocked callback doesn't stall the other subscribers
+	for i := 0; i < 50; i++ {
+		ft.Inject(keyReport(Original2, 1))
+		ft.Inject(keyReport(Original2))
+	}
+	for i := 0; i < 100; i++ {
+		<-events
+	}
+
+	close(release)
+	for i := 0; i < 50; i++ {
+		test.That(t, (<-calls).Kind, test.ShouldEqual, EventKeyPressed)
+		test.That(t, (<-calls).Kind, test.ShouldEqual, EventKeyReleased)
+	}
+}
+
+func TestBtnEventCbUsesStreamDeck(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+
+	closed := make(chan error, 1)
+	sd.SetBtnEventCb(func(s State, e Event) {
+		sd.Events(context.Background())
+		closed <- sd.Close()
+	})
+	ft.Inject(keyReport(Original2, 1))
+
+	select {
+	case err := <-closed:
+		test.That(t, err, test.ShouldBeNil)
+	case <-time.After(5 * time.Second):
+		t.Fatal("closing the Stream Deck from the callback deadlocked")
+	}
+}
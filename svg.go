@@ -0,0 +1,919 @@
+package streamdeck
+
+import (
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/vector"
+)
+
+// SVG is a vector image parsed from a subset of SVG: paths, rects, circles,
+// ellipses, lines, polylines and polygons, nested in groups with
+// transforms. Shapes are filled with solid colors (nonzero rule) and
+// stroked with round joins and caps. Gradients, text, clipping and masks
+// are not supported. SVG implements Icon.
+type SVG struct {
+	viewBox [4]float64 // min x, min y, width, height
+	shapes  []svgShape
+}
+
+type vec2 struct{ x, y float64 }
+
+// affine is the matrix [a c e; b d f] as in SVG transforms.
+type affine [6]float64
+
+var identityTransform = affine{1, 0, 0, 1, 0, 0}
+
+func (m affine) apply(p vec2) vec2 {
+	return vec2{m[0]*p.x + m[2]*p.y + m[4], m[1]*p.x + m[3]*p.y + m[5]}
+}
+
+// mul returns the transform which applies n first, then m.
+func (m affine) mul(n affine) affine {
+	return affine{
+		m[0]*n[0] + m[2]*n[1],
+		m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3],
+		m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4],
+		m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+// scale is the average scale factor, used for stroke widths.
+func (m affine) scale() float64 {
+	return math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+}
+
+// pathOp is a path segment with absolute coordinates. Kind is one of
+// 'M', 'L', 'Q', 'C' and 'Z'; the end point is always the last used point.
+type pathOp struct {
+	kind byte
+	pts  [3]vec2
+}
+
+type paintKind int
+
+const (
+	paintNone paintKind = iota
+	paintColor
+	paintCurrent // currentColor, or an unsupported paint like a gradient
+)
+
+type svgPaint struct {
+	kind  paintKind
+	color color.NRGBA
+}
+
+// svgStyle holds the (inherited) presentation attributes of an element.
+type svgStyle struct {
+	fill, stroke  svgPaint
+	fillOpacity   float64
+	strokeOpacity float64
+	opacity       float64
+	strokeWidth   float64
+	transform     affine
+}
+
+type svgShape struct {
+	path        []pathOp
+	fill        svgPaint
+	stroke      svgPaint
+	fillAlpha   float64
+	strokeAlpha float64
+	strokeWidth float64
+}
+
+// LoadSVGFile parses the SVG file at path.
+func LoadSVGFile(path string) (*SVG, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseSVG(f)
+}
+
+// ParseSVG parses an SVG document. Unsupported elements are ignored.
+func ParseSVG(r io.Reader) (*SVG, error) {
+	dec := xml.NewDecoder(r)
+	s := &SVG{}
+	root := true
+	stack := []svgStyle{{
+		fill:          svgPaint{kind: paintColor, color: color.NRGBA{0, 0, 0, 255}},
+		fillOpacity:   1,
+		strokeOpacity: 1,
+		opacity:       1,
+		strokeWidth:   1,
+		transform:     identityTransform,
+	}}
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			attrs := svgAttrs(el)
+			if root {
+				if el.Name.Local != "svg" {
+					return nil, fmt.Errorf("root element is %s instead of svg", el.Name.Local)
+				}
+				root = false
+				if err := s.parseViewBox(attrs); err != nil {
+					return nil, err
+				}
+			}
+
+			style, err := stack[len(stack)-1].inherit(attrs)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", el.Name.Local, err)
+			}
+
+			switch el.Name.Local {
+			case "svg", "g", "a":
+				stack = append(stack, style)
+				continue
+			case "path", "rect", "circle", "ellipse", "line", "polyline", "polygon":
+				path, err := shapePath(el.Name.Local, attrs)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", el.Name.Local, err)
+				}
+				s.addShape(path, style)
+			}
+			// shapes have no renderable children; everything else
+			// (defs, text, gradients, ...) is not supported
+			if err := dec.Skip(); err != nil {
+				return nil, err
+			}
+
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	if root {
+		return nil, fmt.Errorf("no svg element found")
+	}
+	if s.viewBox[2] <= 0 || s.viewBox[3] <= 0 {
+		s.viewBox = s.bounds()
+	}
+	return s, nil
+}
+
+func svgAttrs(el xml.StartElement) map[string]string {
+	attrs := map[string]string{}
+	for _, a := range el.Attr {
+		attrs[a.Name.Local] = strings.TrimSpace(a.Value)
+	}
+	// properties in the style attribute take precedence
+	for _, decl := range strings.Split(attrs["style"], ";") {
+		if k, v, ok := strings.Cut(decl, ":"); ok {
+			attrs[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return attrs
+}
+
+// parseViewBox reads the coordinate system from the root element. Without
+// viewBox, width and height are used.
+func (s *SVG) parseViewBox(attrs map[string]string) error {
+	if vb, ok := attrs["viewBox"]; ok {
+		nums, err := parseNumbers(vb)
+		if err != nil || len(nums) != 4 {
+			return fmt.Errorf("invalid viewBox %q", vb)
+		}
+		copy(s.viewBox[:], nums)
+		return nil
+	}
+	s.viewBox = [4]float64{0, 0, parseLength(attrs["width"]), parseLength(attrs["height"])}
+	return nil
+}
+
+// bounds returns the bounding box of all points of all shapes.
+func (s *SVG) bounds() [4]float64 {
+	minP := vec2{math.Inf(1), math.Inf(1)}
+	maxP := vec2{math.Inf(-1), math.Inf(-1)}
+	for _, shape := range s.shapes {
+		for _, op := range shape.path {
+			for _, p := range op.pts {
+				minP = vec2{math.Min(minP.x, p.x), math.Min(minP.y, p.y)}
+				maxP = vec2{math.Max(maxP.x, p.x), math.Max(maxP.y, p.y)}
+			}
+		}
+	}
+	if minP.x > maxP.x || minP.y > maxP.y {
+		return [4]float64{0, 0, 1, 1}
+	}
+	return [4]float64{minP.x, minP.y, math.Max(maxP.x-minP.x, 1e-3), math.Max(maxP.y-minP.y, 1e-3)}
+}
+
+func (s *SVG) addShape(path []pathOp, style svgStyle) {
+	if len(path) == 0 {
+		return
+	}
+	for i, op := range path {
+		for j := range op.pts {
+			path[i].pts[j] = style.transform.apply(op.pts[j])
+		}
+	}
+	s.shapes = append(s.shapes, svgShape{
+		path:        path,
+		fill:        style.fill,
+		stroke:      style.stroke,
+		fillAlpha:   style.fillOpacity * style.opacity,
+		strokeAlpha: style.strokeOpacity * style.opacity,
+		strokeWidth: style.strokeWidth * style.transform.scale(),
+	})
+}
+
+// inherit returns the style of an element with the given attributes whose
+// parent has the style st. Opacity is multiplied with the parent's.
+func (st svgStyle) inherit(attrs map[string]string) (svgStyle, error) {
+	var err error
+	if v, ok := attrs["fill"]; ok {
+		st.fill = parsePaint(v)
+	}
+	if v, ok := attrs["stroke"]; ok {
+		st.stroke = parsePaint(v)
+	}
+	if v, ok := attrs["fill-opacity"]; ok {
+		st.fillOpacity = parseOpacity(v)
+	}
+	if v, ok := attrs["stroke-opacity"]; ok {
+		st.strokeOpacity = parseOpacity(v)
+	}
+	if v, ok := attrs["opacity"]; ok {
+		st.opacity *= parseOpacity(v)
+	}
+	if v, ok := attrs["stroke-width"]; ok {
+		st.strokeWidth = parseLength(v)
+	}
+	if v, ok := attrs["transform"]; ok {
+		var m affine
+		m, err = parseTransform(v)
+		st.transform = st.transform.mul(m)
+	}
+	return st, err
+}
+
+var namedColors = map[string]color.NRGBA{
+	"black":   {0, 0, 0, 255},
+	"white":   {255, 255, 255, 255},
+	"red":     {255, 0, 0, 255},
+	"lime":    {0, 255, 0, 255},
+	"green":   {0, 128, 0, 255},
+	"blue":    {0, 0, 255, 255},
+	"yellow":  {255, 255, 0, 255},
+	"cyan":    {0, 255, 255, 255},
+	"magenta": {255, 0, 255, 255},
+	"orange":  {255, 165, 0, 255},
+	"purple":  {128, 0, 128, 255},
+	"gray":    {128, 128, 128, 255},
+	"grey":    {128, 128, 128, 255},
+	"silver":  {192, 192, 192, 255},
+}
+
+// parsePaint parses a fill or stroke value. Paints which aren't supported
+// (e.g. gradients) are drawn like currentColor.
+func parsePaint(v string) svgPaint {
+	v = strings.ToLower(strings.TrimSpace(v))
+	switch {
+	case v == "none" || v == "transparent":
+		return svgPaint{kind: paintNone}
+	case strings.HasPrefix(v, "#"):
+		hex := v[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if n, err := strconv.ParseUint(hex, 16, 32); err == nil && len(hex) == 6 {
+			return svgPaint{kind: paintColor, color: color.NRGBA{uint8(n >> 16), uint8(n >> 8), uint8(n), 255}}
+		}
+	case strings.HasPrefix(v, "rgb(") && strings.HasSuffix(v, ")"):
+		parts := strings.Split(v[4:len(v)-1], ",")
+		if len(parts) == 3 {
+			var c [3]uint8
+			ok := true
+			for i, p := range parts {
+				p = strings.TrimSpace(p)
+				scale := 1.0
+				if strings.HasSuffix(p, "%") {
+					p, scale = p[:len(p)-1], 2.55
+				}
+				f, err := strconv.ParseFloat(p, 64)
+				ok = ok && err == nil
+				c[i] = uint8(math.Max(0, math.Min(255, math.Round(f*scale))))
+			}
+			if ok {
+				return svgPaint{kind: paintColor, color: color.NRGBA{c[0], c[1], c[2], 255}}
+			}
+		}
+	default:
+		if c, ok := namedColors[v]; ok {
+			return svgPaint{kind: paintColor, color: c}
+		}
+	}
+	return svgPaint{kind: paintCurrent}
+}
+
+func parseOpacity(v string) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+	if err != nil {
+		return 1
+	}
+	if strings.HasSuffix(v, "%") {
+		f /= 100
+	}
+	return math.Max(0, math.Min(1, f))
+}
+
+// parseLength parses a length in user units. Units are ignored.
+func parseLength(v string) float64 {
+	v = strings.TrimRight(strings.TrimSpace(v), "abcdefghijklmnopqrstuvwxyz%")
+	f, _ := strconv.ParseFloat(v, 64)
+	return f
+}
+
+// parseTransform parses a list of transform functions like
+// "translate(4 4) rotate(45)".
+func parseTransform(v string) (affine, error) {
+	m := identityTransform
+	for {
+		v = strings.TrimLeft(v, " \t\r\n,")
+		if v == "" {
+			return m, nil
+		}
+		name, rest, ok := strings.Cut(v, "(")
+		if !ok {
+			return m, fmt.Errorf("invalid transform %q", v)
+		}
+		args, rest, ok := strings.Cut(rest, ")")
+		if !ok {
+			return m, fmt.Errorf("invalid transform %q", v)
+		}
+		v = rest
+		a, err := parseNumbers(args)
+		if err != nil {
+			return m, err
+		}
+
+		var t affine
+		name = strings.TrimSpace(name)
+		switch {
+		case name == "matrix" && len(a) == 6:
+			copy(t[:], a)
+		case name == "translate" && len(a) == 1:
+			t = affine{1, 0, 0, 1, a[0], 0}
+		case name == "translate" && len(a) == 2:
+			t = affine{1, 0, 0, 1, a[0], a[1]}
+		case name == "scale" && len(a) == 1:
+			t = affine{a[0], 0, 0, a[0], 0, 0}
+		case name == "scale" && len(a) == 2:
+			t = affine{a[0], 0, 0, a[1], 0, 0}
+		case name == "rotate" && (len(a) == 1 || len(a) == 3):
+			sin, cos := math.Sincos(a[0] * math.Pi / 180)
+			t = affine{cos, sin, -sin, cos, 0, 0}
+			if len(a) == 3 {
+				t = affine{1, 0, 0, 1, a[1], a[2]}.mul(t).mul(affine{1, 0, 0, 1, -a[1], -a[2]})
+			}
+		case name == "skewX" && len(a) == 1:
+			t = affine{1, 0, math.Tan(a[0] * math.Pi / 180), 1, 0, 0}
+		case name == "skewY" && len(a) == 1:
+			t = affine{1, math.Tan(a[0] * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			return m, fmt.Errorf("invalid transform %s(%s)", name, args)
+		}
+		m = m.mul(t)
+	}
+}
+
+func parseNumbers(v string) ([]float64, error) {
+	sc := pathScanner{s: v}
+	var nums []float64
+	for sc.skipSeparators(); !sc.done(); sc.skipSeparators() {
+		n, err := sc.number()
+		if err != nil {
+			return nil, err
+		}
+		nums = append(nums, n)
+	}
+	return nums, nil
+}
+
+// shapePath converts a basic shape into a path.
+func shapePath(name string, attrs map[string]string) ([]pathOp, error) {
+	num := func(k string) float64 { return parseLength(attrs[k]) }
+	var b pathBuilder
+
+	switch name {
+	case "path":
+		return parsePathData(attrs["d"])
+
+	case "rect":
+		x, y, w, h := num("x"), num("y"), num("width"), num("height")
+		if w <= 0 || h <= 0 {
+			return nil, nil
+		}
+		rx, rxOK := attrs["rx"]
+		ry, ryOK := attrs["ry"]
+		if !rxOK {
+			rx = ry
+		}
+		if !ryOK {
+			ry = rx
+		}
+		rX := math.Min(parseLength(rx), w/2)
+		rY := math.Min(parseLength(ry), h/2)
+		if rX <= 0 || rY <= 0 {
+			b.moveTo(vec2{x, y})
+			b.lineTo(vec2{x + w, y})
+			b.lineTo(vec2{x + w, y + h})
+			b.lineTo(vec2{x, y + h})
+			b.close()
+			break
+		}
+		b.moveTo(vec2{x + rX, y})
+		b.lineTo(vec2{x + w - rX, y})
+		b.arcTo(rX, rY, 0, false, true, vec2{x + w, y + rY})
+		b.lineTo(vec2{x + w, y + h - rY})
+		b.arcTo(rX, rY, 0, false, true, vec2{x + w - rX, y + h})
+		b.lineTo(vec2{x + rX, y + h})
+		b.arcTo(rX, rY, 0, false, true, vec2{x, y + h - rY})
+		b.lineTo(vec2{x, y + rY})
+		b.arcTo(rX, rY, 0, false, true, vec2{x + rX, y})
+		b.close()
+
+	case "circle", "ellipse":
+		cx, cy := num("cx"), num("cy")
+		rx, ry := num("r"), num("r")
+		if name == "ellipse" {
+			rx, ry = num("rx"), num("ry")
+		}
+		if rx <= 0 || ry <= 0 {
+			return nil, nil
+		}
+		b.moveTo(vec2{cx + rx, cy})
+		b.arcTo(rx, ry, 0, false, true, vec2{cx - rx, cy})
+		b.arcTo(rx, ry, 0, false, true, vec2{cx + rx, cy})
+		b.close()
+
+	case "line":
+		b.moveTo(vec2{num("x1"), num("y1")})
+		b.lineTo(vec2{num("x2"), num("y2")})
+
+	case "polyline", "polygon":
+		nums, err := parseNumbers(attrs["points"])
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i+1 < len(nums); i += 2 {
+			if i == 0 {
+				b.moveTo(vec2{nums[i], nums[i+1]})
+			} else {
+				b.lineTo(vec2{nums[i], nums[i+1]})
+			}
+		}
+		if name == "polygon" && len(b.ops) > 0 {
+			b.close()
+		}
+	}
+	return b.ops, nil
+}
+
+// pathBuilder collects path segments and keeps track of the state needed
+// for relative and smooth commands.
+type pathBuilder struct {
+	ops   []pathOp
+	cur   vec2
+	start vec2
+	ctrl  vec2 // last control point of a curve, for S and T
+	last  byte // kind of the last curve ('C' or 'Q'), for S and T
+}
+
+func (b *pathBuilder) moveTo(p vec2) {
+	b.ops = append(b.ops, pathOp{kind: 'M', pts: [3]vec2{p}})
+	b.cur, b.start, b.last = p, p, 0
+}
+
+func (b *pathBuilder) lineTo(p vec2) {
+	if len(b.ops) == 0 {
+		b.moveTo(b.cur)
+	}
+	b.ops = append(b.ops, pathOp{kind: 'L', pts: [3]vec2{p}})
+	b.cur, b.last = p, 0
+}
+
+func (b *pathBuilder) quadTo(c, p vec2) {
+	if len(b.ops) == 0 {
+		b.moveTo(b.cur)
+	}
+	b.ops = append(b.ops, pathOp{kind: 'Q', pts: [3]vec2{c, p}})
+	b.cur, b.ctrl, b.last = p, c, 'Q'
+}
+
+func (b *pathBuilder) cubeTo(c1, c2, p vec2) {
+	if len(b.ops) == 0 {
+		b.moveTo(b.cur)
+	}
+	b.ops = append(b.ops, pathOp{kind: 'C', pts: [3]vec2{c1, c2, p}})
+	b.cur, b.ctrl, b.last = p, c2, 'C'
+}
+
+func (b *pathBuilder) close() {
+	b.ops = append(b.ops, pathOp{kind: 'Z', pts: [3]vec2{b.start}})
+	b.cur, b.last = b.start, 0
+}
+
+// reflected returns the control point for a smooth curve of kind.
+func (b *pathBuilder) reflected(kind byte) vec2 {
+	if b.last != kind {
+		return b.cur
+	}
+	return vec2{2*b.cur.x - b.ctrl.x, 2*b.cur.y - b.ctrl.y}
+}
+
+// arcTo adds an elliptical arc (as in SVG) approximated by cubic curves.
+func (b *pathBuilder) arcTo(rx, ry, rotation float64, large, sweep bool, p vec2) {
+	c0 := b.cur
+	if c0 == p {
+		return
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		b.lineTo(p)
+		return
+	}
+
+	// conversion to center parameterization, see SVG 1.1 appendix F.6.5
+	sinPhi, cosPhi := math.Sincos(rotation * math.Pi / 180)
+	dx, dy := (c0.x-p.x)/2, (c0.y-p.y)/2
+	x1 := cosPhi*dx + sinPhi*dy
+	y1 := -sinPhi*dx + cosPhi*dy
+	if l := x1*x1/(rx*rx) + y1*y1/(ry*ry); l > 1 {
+		rx, ry = rx*math.Sqrt(l), ry*math.Sqrt(l)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	cxp, cyp := coef*rx*y1/ry, -coef*ry*x1/rx
+	cx := cosPhi*cxp - sinPhi*cyp + (c0.x+p.x)/2
+	cy := sinPhi*cxp + cosPhi*cyp + (c0.y+p.y)/2
+
+	angle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	theta := angle(1, 0, (x1-cxp)/rx, (y1-cyp)/ry)
+	delta := angle((x1-cxp)/rx, (y1-cyp)/ry, (-x1-cxp)/rx, (-y1-cyp)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	// one cubic curve per quarter of the ellipse at most
+	n := int(math.Ceil(math.Abs(delta) / (math.Pi / 2)))
+	step := delta / float64(n)
+	k := 4.0 / 3 * math.Tan(step/4)
+	onEllipse := func(x, y float64) vec2 {
+		return vec2{cx + rx*cosPhi*x - ry*sinPhi*y, cy + rx*sinPhi*x + ry*cosPhi*y}
+	}
+	for i := 0; i < n; i++ {
+		s1, c1 := math.Sincos(theta + float64(i)*step)
+		s2, c2 := math.Sincos(theta + float64(i+1)*step)
+		end := onEllipse(c2, s2)
+		if i == n-1 {
+			end = p
+		}
+		b.cubeTo(onEllipse(c1-k*s1, s1+k*c1), onEllipse(c2+k*s2, s2-k*c2), end)
+	}
+}
+
+// pathScanner reads the numbers and flags of path data.
+type pathScanner struct {
+	s string
+	i int
+}
+
+func (sc *pathScanner) done() bool { return sc.i >= len(sc.s) }
+
+func (sc *pathScanner) skipSeparators() {
+	for !sc.done() && strings.IndexByte(" \t\r\n,", sc.s[sc.i]) >= 0 {
+		sc.i++
+	}
+}
+
+// hasNumber returns true if a number follows.
+func (sc *pathScanner) hasNumber() bool {
+	sc.skipSeparators()
+	return !sc.done() && strings.IndexByte("0123456789+-.", sc.s[sc.i]) >= 0
+}
+
+func (sc *pathScanner) number() (float64, error) {
+	sc.skipSeparators()
+	start := sc.i
+	if !sc.done() && (sc.s[sc.i] == '+' || sc.s[sc.i] == '-') {
+		sc.i++
+	}
+	dot, exp := false, false
+scan:
+	for ; !sc.done(); sc.i++ {
+		c := sc.s[sc.i]
+		switch {
+		case c >= '0' && c <= '9':
+		case c == '.' && !dot && !exp:
+			dot = true
+		case (c == 'e' || c == 'E') && !exp && sc.i > start:
+			exp = true
+			if sc.i+1 < len(sc.s) && (sc.s[sc.i+1] == '+' || sc.s[sc.i+1] == '-') {
+				sc.i++
+			}
+		default:
+			break scan
+		}
+	}
+	f, err := strconv.ParseFloat(sc.s[start:sc.i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number at offset %d of %q", start, sc.s)
+	}
+	return f, nil
+}
+
+// flag reads an arc flag, which doesn't need a separator ("a1 1 0 01 1 1").
+func (sc *pathScanner) flag() (bool, error) {
+	sc.skipSeparators()
+	if sc.done() || (sc.s[sc.i] != '0' && sc.s[sc.i] != '1') {
+		return false, fmt.Errorf("invalid flag at offset %d of %q", sc.i, sc.s)
+	}
+	sc.i++
+	return sc.s[sc.i-1] == '1', nil
+}
+
+// parsePathData parses the d attribute of a path.
+func parsePathData(d string) ([]pathOp, error) {
+	var b pathBuilder
+	sc := pathScanner{s: d}
+	var cmd byte
+
+	for sc.skipSeparators(); !sc.done(); sc.skipSeparators() {
+		c := sc.s[sc.i]
+		if strings.IndexByte("MmLlHhVvCcSsQqTtAaZz", c) >= 0 {
+			cmd = c
+			sc.i++
+		} else if cmd == 0 || cmd == 'Z' || cmd == 'z' || !sc.hasNumber() {
+			return nil, fmt.Errorf("unexpected %q at offset %d of path", c, sc.i)
+		}
+
+		// read n coordinates
+		var args [7]float64
+		read := func(n int) error {
+			for i := 0; i < n; i++ {
+				v, err := sc.number()
+				if err != nil {
+					return err
+				}
+				args[i] = v
+			}
+			return nil
+		}
+		rel := vec2{}
+		if cmd >= 'a' && cmd <= 'z' {
+			rel = b.cur
+		}
+		pt := func(i int) vec2 { return vec2{rel.x + args[i], rel.y + args[i+1]} }
+
+		var err error
+		switch cmd {
+		case 'M', 'm':
+			if err = read(2); err == nil {
+				b.moveTo(pt(0))
+				// further coordinate pairs are implicit line commands
+				cmd = cmd - 'M' + 'L'
+			}
+		case 'L', 'l':
+			if err = read(2); err == nil {
+				b.lineTo(pt(0))
+			}
+		case 'H', 'h':
+			if err = read(1); err == nil {
+				b.lineTo(vec2{rel.x + args[0], b.cur.y})
+			}
+		case 'V', 'v':
+			if err = read(1); err == nil {
+				b.lineTo(vec2{b.cur.x, rel.y + args[0]})
+			}
+		case 'C', 'c':
+			if err = read(6); err == nil {
+				b.cubeTo(pt(0), pt(2), pt(4))
+			}
+		case 'S', 's':
+			if err = read(4); err == nil {
+				b.cubeTo(b.reflected('C'), pt(0), pt(2))
+			}
+		case 'Q', 'q':
+			if err = read(4); err == nil {
+				b.quadTo(pt(0), pt(2))
+			}
+		case 'T', 't':
+			if err = read(2); err == nil {
+				b.quadTo(b.reflected('Q'), pt(0))
+			}
+		case 'A', 'a':
+			var large, sweep bool
+			if err = read(3); err != nil {
+				break
+			}
+			rx, ry, rot := args[0], args[1], args[2]
+			if large, err = sc.flag(); err != nil {
+				break
+			}
+			if sweep, err = sc.flag(); err != nil {
+				break
+			}
+			if err = read(2); err == nil {
+				b.arcTo(rx, ry, rot, large, sweep, pt(0))
+			}
+		case 'Z', 'z':
+			b.close()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b.ops, nil
+}
+
+// flatten converts the path into polylines. Closed subpaths end with
+// their start point.
+func flatten(path []pathOp, m affine) [][]vec2 {
+	var lines [][]vec2
+	var cur []vec2
+	var last vec2
+	flush := func() {
+		if len(cur) > 1 {
+			lines = append(lines, cur)
+		}
+		cur = nil
+	}
+
+	for _, op := range path {
+		switch op.kind {
+		case 'M':
+			flush()
+			last = m.apply(op.pts[0])
+			cur = []vec2{last}
+		case 'L':
+			last = m.apply(op.pts[0])
+			cur = append(cur, last)
+		case 'Q', 'C':
+			ctrl := []vec2{last, m.apply(op.pts[0]), m.apply(op.pts[1])}
+			if op.kind == 'C' {
+				ctrl = append(ctrl, m.apply(op.pts[2]))
+			}
+			// about one segment per two pixels
+			length := 0.0
+			for i := 1; i < len(ctrl); i++ {
+				length += math.Hypot(ctrl[i].x-ctrl[i-1].x, ctrl[i].y-ctrl[i-1].y)
+			}
+			n := max(1, min(64, int(length/2)))
+			for i := 1; i <= n; i++ {
+				cur = append(cur, bezier(ctrl, float64(i)/float64(n)))
+			}
+			last = ctrl[len(ctrl)-1]
+		case 'Z':
+			if len(cur) > 0 {
+				cur = append(cur, cur[0])
+				last = cur[0]
+			}
+			flush()
+			cur = []vec2{last}
+		}
+	}
+	flush()
+	return lines
+}
+
+// bezier evaluates the curve with the control points at t.
+func bezier(ctrl []vec2, t float64) vec2 {
+	pts := append([]vec2(nil), ctrl...)
+	for n := len(pts) - 1; n > 0; n-- {
+		for i := 0; i < n; i++ {
+			pts[i] = vec2{pts[i].x + t*(pts[i+1].x-pts[i].x), pts[i].y + t*(pts[i+1].y-pts[i].y)}
+		}
+	}
+	return pts[0]
+}
+
+// fillMask rasterizes the polylines (closing them) into a mask of size.
+func fillMask(size image.Point, lines [][]vec2) *image.Alpha {
+	z := vector.NewRasterizer(size.X, size.Y)
+	for _, l := range lines {
+		z.MoveTo(float32(l[0].x), float32(l[0].y))
+		for _, p := range l[1:] {
+			z.LineTo(float32(p.x), float32(p.y))
+		}
+		z.ClosePath()
+	}
+	mask := image.NewAlpha(image.Rectangle{Max: size})
+	z.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+	return mask
+}
+
+// strokeMask rasterizes the outlines of the polylines with round joins and
+// caps. All polygons have the same orientation, so overlaps add up instead
+// of cancelling each other out.
+func strokeMask(size image.Point, lines [][]vec2, width float64) *image.Alpha {
+	hw := width / 2
+	var polys [][]vec2
+	for _, l := range lines {
+		for i, p := range l {
+			polys = append(polys, disc(p, hw))
+			if i == 0 {
+				continue
+			}
+			q := l[i-1]
+			d := math.Hypot(p.x-q.x, p.y-q.y)
+			if d == 0 {
+				continue
+			}
+			n := vec2{-(p.y - q.y) / d * hw, (p.x - q.x) / d * hw}
+			polys = append(polys, []vec2{
+				{q.x + n.x, q.y + n.y}, {p.x + n.x, p.y + n.y},
+				{p.x - n.x, p.y - n.y}, {q.x - n.x, q.y - n.y},
+			})
+		}
+	}
+	return fillMask(size, polys)
+}
+
+// disc returns a polygon approximating a circle, oriented like the stroke
+// segments of strokeMask.
+func disc(c vec2, r float64) []vec2 {
+	n := max(8, min(64, int(2*math.Pi*r/1.5)))
+	pts := make([]vec2, n)
+	for i := range pts {
+		sin, cos := math.Sincos(-2 * math.Pi * float64(i) / float64(n))
+		pts[i] = vec2{c.x + r*cos, c.y + r*sin}
+	}
+	return pts
+}
+
+// rgba returns the color of a paint with the given opacity. If tint is
+// set, it replaces all colors.
+func (p svgPaint) rgba(alpha float64, tint color.Color) color.Color {
+	c := p.color
+	if tint != nil {
+		c = color.NRGBAModel.Convert(tint).(color.NRGBA)
+	} else if p.kind == paintCurrent {
+		c = color.NRGBA{0, 0, 0, 255}
+	}
+	return fade(c, alpha)
+}
+
+// DrawIcon draws the SVG centered into rect, scaled as large as possible
+// while keeping its aspect ratio. If tint is not nil, all shapes are drawn
+// in tint, otherwise in their own colors (currentColor is black).
+func (s *SVG) DrawIcon(dst draw.Image, rect image.Rectangle, tint color.Color) {
+	if rect.Empty() {
+		return
+	}
+	vb := s.viewBox
+	scale := math.Min(float64(rect.Dx())/vb[2], float64(rect.Dy())/vb[3])
+	m := affine{scale, 0, 0, scale,
+		(float64(rect.Dx())-vb[2]*scale)/2 - vb[0]*scale,
+		(float64(rect.Dy())-vb[3]*scale)/2 - vb[1]*scale,
+	}
+
+	for _, shape := range s.shapes {
+		lines := flatten(shape.path, m)
+		if len(lines) == 0 {
+			continue
+		}
+		if shape.fill.kind != paintNone {
+			// open subpaths are filled as if closed
+			mask := fillMask(rect.Size(), lines)
+			draw.DrawMask(dst, rect, image.NewUniform(shape.fill.rgba(shape.fillAlpha, tint)),
+				image.Point{}, mask, image.Point{}, draw.Over)
+		}
+		if shape.stroke.kind != paintNone && shape.strokeWidth > 0 {
+			mask := strokeMask(rect.Size(), lines, shape.strokeWidth*scale)
+			draw.DrawMask(dst, rect, image.NewUniform(shape.stroke.rgba(shape.strokeAlpha, tint)),
+				image.Point{}, mask, image.Point{}, draw.Over)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package streamdeck
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func renderSVG(t *testing.T, src string, size int, tint color.Color) *image.RGBA {
+	t.Helper()
+	s, err := ParseSVG(strings.NewReader(src))
+	test.That(t, err, test.ShouldBeNil)
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	s.DrawIcon(img, img.Bounds(), tint)
+	return img
+}
+
+func TestParsePathData(t *testing.T) {
+	ops, err := parsePathData("M10,10 l5-5 20 0h-5v10 z m1 1 2 2")
+	test.That(t, err, test.ShouldBeNil)
+	ends := []vec2{}
+	for _, op := range ops {
+		ends = append(ends, op.pts[0])
+	}
+	test.That(t, string([]byte{ops[0].kind, ops[1].kind, ops[4].kind, ops[5].kind, ops[6].kind, ops[7].kind}), test.ShouldEqual, "MLLZML")
+	test.That(t, ends[:5], test.ShouldResemble, []vec2{{10, 10}, {15, 5}, {35, 5}, {30, 5}, {30, 15}})
+	// relative moves after close start from the subpath start
+	test.That(t, ends[6], test.ShouldResemble, vec2{11, 11})
+	test.That(t, ends[7], test.ShouldResemble, vec2{13, 13})
+
+	// arcs end exactly at their end point, flags don't need separators
+	ops, err = parsePathData("M0 0a5 5 0 01 10 0")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ops[len(ops)-1].kind, test.ShouldEqual, byte('C'))
+	test.That(t, ops[len(ops)-1].pts[2], test.ShouldResemble, vec2{10, 0})
+	// the half circle bulges upwards (sweep flag set)
+	mid := bezier([]vec2{{0, 0}, ops[1].pts[0], ops[1].pts[1], ops[1].pts[2]}, 1)
+	test.That(t, math.Hypot(mid.x-5, mid.y), test.ShouldAlmostEqual, 5, 0.01)
+
+	_, err = parsePathData("M0 0 L10")
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = parsePathData("X 1 2")
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestSVGFillAndViewBox(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	img := renderSVG(t, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 24 24">
+		<rect x="0" y="0" width="12" height="24" fill="#f00"/>
+	</svg>`, 48, nil)
+	test.That(t, img.RGBAAt(10, 24), test.ShouldResemble, red)
+	test.That(t, img.RGBAAt(30, 24), test.ShouldResemble, color.RGBA{})
+
+	// a wide viewBox is letterboxed and centered vertically
+	img = renderSVG(t, `<svg viewBox="0 0 20 10"><rect width="20" height="10"/></svg>`, 40, nil)
+	test.That(t, inkBounds(img, color.RGBA{}), test.ShouldResemble, image.Rect(0, 10, 40, 30))
+}
+
+func TestSVGGroupsAndTransforms(t *testing.T) {
+	img := renderSVG(t, `<svg width="24" height="24">
+		<defs><rect width="24" height="24" fill="blue"/></defs>
+		<g fill="red" transform="translate(12 0)">
+			<rect width="12" height="12"/>
+			<circle cx="6" cy="18" r="4" style="fill:rgb(0,255,0);opacity:0.5"/>
+		</g>
+	</svg>`, 24, nil)
+	test.That(t, img.RGBAAt(18, 6), test.ShouldResemble, color.RGBA{255, 0, 0, 255})
+	test.That(t, img.RGBAAt(6, 6), test.ShouldResemble, color.RGBA{})
+	c := img.RGBAAt(18, 18)
+	test.That(t, c.G, test.ShouldBeBetween, 120, 136)
+	test.That(t, c.R, test.ShouldEqual, 0)
+	test.That(t, img.RGBAAt(13, 13), test.ShouldResemble, color.RGBA{})
+}
+
+func TestSVGStrokeAndTint(t *testing.T) {
+	src := `<svg viewBox="0 0 10 10">
+		<line x1="1" y1="5" x2="9" y2="5" stroke="currentColor" stroke-width="2"/>
+	</svg>`
+	img := renderSVG(t, src, 40, nil)
+	ink := inkBounds(img, color.RGBA{})
+	// 2 units (8 pixel) wide with round caps
+	test.That(t, ink.Min.Y, test.ShouldBeBetweenOrEqual, 15, 17)
+	test.That(t, ink.Max.Y, test.ShouldBeBetweenOrEqual, 24, 26)
+	test.That(t, ink.Min.X, test.ShouldBeBetweenOrEqual, 0, 1)
+	test.That(t, img.RGBAAt(20, 20), test.ShouldResemble, color.RGBA{0, 0, 0, 255})
+
+	tint := color.RGBA{0, 200, 255, 255}
+	img = renderSVG(t, src, 40, tint)
+	test.That(t, img.RGBAAt(20, 20), test.ShouldResemble, tint)
+}
+
+func TestParseSVGErrors(t *testing.T) {
+	for _, src := range []string{
+		`<html/>`,
+		`<svg viewBox="0 0 10"/>`,
+		`<svg><path d="M 0 0 L"/></svg>`,
+		`<svg><g transform="spin(4)"/></svg>`,
+		`<svg><rect`,
+	} {
+		_, err := ParseSVG(strings.NewReader(src))
+		test.That(t, err, test.ShouldNotBeNil)
+	}
+}
@@ -0,0 +1,320 @@
+package streamdeck
+
+import (
+	"image"
+	"image/draw"
+	"strings"
+	"unicode"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// DefaultFontSize is used for TextLines without a FontSize.
+const DefaultFontSize = 14
+
+// ellipsis is appended to truncated lines. If the font has no glyph for
+// it, three dots are used instead.
+const ellipsis = "…"
+
+// HAlign is the horizontal alignment of text.
+type HAlign int
+
+const (
+	AlignLeft HAlign = iota
+	AlignCenter
+	AlignRight
+)
+
+// VAlign is the vertical alignment of text.
+type VAlign int
+
+const (
+	AlignTop VAlign = iota
+	AlignMiddle
+	AlignBottom
+)
+
+// TextLayout lays out the lines of a TextButton automatically. The lines
+// are stacked as a block and aligned within the key; PosX and PosY of the
+// TextLines are ignored.
+type TextLayout struct {
+	HAlign HAlign
+	VAlign VAlign
+	// Padding is the distance (in pixel) between the text and the key border.
+	Padding int
+	// Wrap breaks lines which are too wide at word boundaries.
+	Wrap bool
+	// MinFontSize enables shrink-to-fit: if the text doesn't fit, all font
+	// sizes are reduced proportionally until it fits, but not below
+	// MinFontSize.
+	MinFontSize float64
+	// Ellipsis truncates text which still doesn't fit with "…".
+	Ellipsis bool
+	// LineSpacing is the distance between lines as a multiple of the line
+	// height. Defaults to 1.
+	LineSpacing float64
+}
+
+// textRun is a piece of text placed on an image.
+type textRun struct {
+	line   TextLine
+	face   font.Face
+	text   string
+	width  fixed.Int26_6
+	ascent fixed.Int26_6
+	height fixed.Int26_6
+	dot    fixed.Point26_6 // baseline origin
+}
+
+// lineFace returns the font face of a TextLine at the given size. Glyphs
+// missing in the font are taken from its fallbacks in DefaultFontRegistry.
+func lineFace(line TextLine, size float64) font.Face {
+	return DefaultFontRegistry.lineFace(line, size)
+}
+
+func lineSize(line TextLine) float64 {
+	if line.FontSize <= 0 {
+		return DefaultFontSize
+	}
+	return line.FontSize
+}
+
+func newTextRun(line TextLine, face font.Face, text string) textRun {
+	m := face.Metrics()
+	return textRun{
+		line:   line,
+		face:   face,
+		text:   text,
+		width:  font.MeasureString(face, text),
+		ascent: m.Ascent,
+		height: m.Ascent + m.Descent,
+	}
+}
+
+// MeasureText returns the width and height (in pixel) of a single line of
+// text in the given font and size. If f is nil, MonoRegular is used.
+func MeasureText(f *truetype.Font, size float64, text string) (int, int) {
+	return measureLine(TextLine{Text: text, Font: f, FontSize: size})
+}
+
+// measureLine returns the width and height (in pixel) of a TextLine.
+func measureLine(line TextLine) (int, int) {
+	r := newTextRun(line, lineFace(line, lineSize(line)), line.Text)
+	return r.width.Ceil(), r.height.Ceil()
+}
+
+// absoluteRuns places the lines at their PosX / PosY, which denote the top
+// left corner of the line.
+func absoluteRuns(rect image.Rectangle, lines []TextLine) []textRun {
+	runs := make([]textRun, 0, len(lines))
+	for _, line := range lines {
+		r := newTextRun(line, lineFace(line, lineSize(line)), line.Text)
+		r.dot = fixed.P(rect.Min.X+line.PosX, rect.Min.Y+line.PosY)
+		r.dot.Y += r.ascent
+		runs = append(runs, r)
+	}
+	return runs
+}
+
+// layoutRuns places the lines within rect according to the TextLayout.
+func layoutRuns(rect image.Rectangle, lines []TextLine, l TextLayout) []textRun {
+	inner := rect.Inset(l.Padding)
+	width := fixed.I(inner.Dx())
+	height := fixed.I(inner.Dy())
+	spacing := l.LineSpacing
+	if spacing <= 0 {
+		spacing = 1
+	}
+
+	maxSize := 0.0
+	for _, line := range lines {
+		maxSize = max(maxSize, lineSize(line))
+	}
+
+	// shrink in steps of half a point of the largest font
+	scale := 1.0
+	var runs []textRun
+	for {
+		runs = wrapRuns(lines, scale, width, l.Wrap)
+		fits := blockHeight(runs, spacing) <= height
+		for _, r := range runs {
+			fits = fits && r.width <= width
+		}
+		if fits || l.MinFontSize <= 0 || maxSize == 0 {
+			break
+		}
+		next := scale - 0.5/maxSize
+		if !linesAtLeast(lines, next, l.MinFontSize) {
+			break
+		}
+		scale = next
+	}
+
+	if l.Ellipsis {
+		runs = truncateRuns(runs, width, height, spacing)
+	}
+
+	y := fixed.I(inner.Min.Y)
+	switch l.VAlign {
+	case AlignMiddle:
+		y += (height - blockHeight(runs, spacing)) / 2
+	case AlignBottom:
+		y += height - blockHeight(runs, spacing)
+	}
+
+	for i := range runs {
+		x := fixed.I(inner.Min.X)
+		switch l.HAlign {
+		case AlignCenter:
+			x += (width - runs[i].width) / 2
+		case AlignRight:
+			x += width - runs[i].width
+		}
+		runs[i].dot = fixed.Point26_6{X: x, Y: y + runs[i].ascent}
+		y += lineAdvance(runs[i], spacing)
+	}
+	return runs
+}
+
+func linesAtLeast(lines []TextLine, scale, minSize float64) bool {
+	for _, line := range lines {
+		if lineSize(line)*scale < minSize {
+			return false
+		}
+	}
+	return true
+}
+
+func lineAdvance(r textRun, spacing float64) fixed.Int26_6 {
+	return fixed.Int26_6(float64(r.height) * spacing)
+}
+
+// blockHeight is the height of the stacked lines. The spacing isn't
+// applied after the last line.
+func blockHeight(runs []textRun, spacing float64) fixed.Int26_6 {
+	h := fixed.Int26_6(0)
+	for i, r := range runs {
+		if i == len(runs)-1 {
+			h += r.height
+			break
+		}
+		h += lineAdvance(r, spacing)
+	}
+	return h
+}
+
+// wrapRuns converts the lines into runs at the given scale, breaking them
+// into several runs if wrap is set.
+func wrapRuns(lines []TextLine, scale float64, width fixed.Int26_6, wrap bool) []textRun {
+	var runs []textRun
+	for _, line := range lines {
+		face := lineFace(line, lineSize(line)*scale)
+		if !wrap {
+			runs = append(runs, newTextRun(line, face, line.Text))
+			continue
+		}
+		for _, text := range wrapText(face, line.Text, width) {
+			runs = append(runs, newTextRun(line, face, text))
+		}
+	}
+	return runs
+}
+
+// wrapText breaks text at spaces so that the lines fit into width. Words
+// which are wider than width on their own are broken between characters.
+func wrapText(face font.Face, text string, width fixed.Int26_6) []string {
+	words := strings.FieldsFunc(text, unicode.IsSpace)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	current := ""
+	for _, word := range words {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if font.MeasureString(face, candidate) <= width {
+			current = candidate
+			continue
+		}
+		if current != "" {
+			lines = append(lines, current)
+		}
+		current = word
+		// break words which don't fit on a line of their own
+		for len([]rune(current)) > 1 && font.MeasureString(face, current) > width {
+			runes := []rune(current)
+			n := len(runes) - 1
+			for n > 1 && font.MeasureString(face, string(runes[:n])) > width {
+				n--
+			}
+			lines = append(lines, string(runes[:n]))
+			current = string(runes[n:])
+		}
+	}
+	return append(lines, current)
+}
+
+// truncateRuns drops the runs which don't fit into height and shortens the
+// runs which are wider than width, ending them with an ellipsis.
+func truncateRuns(runs []textRun, width, height fixed.Int26_6, spacing float64) []textRun {
+	cut := false
+	for len(runs) > 1 && blockHeight(runs, spacing) > height {
+		runs = runs[:len(runs)-1]
+		cut = true
+	}
+	for i := range runs {
+		last := i == len(runs)-1
+		if runs[i].width <= width && !(last && cut) {
+			continue
+		}
+		runs[i] = ellipsize(runs[i], width)
+	}
+	return runs
+}
+
+func ellipsize(r textRun, width fixed.Int26_6) textRun {
+	suffix := ellipsis
+	if !hasGlyph(r.face, []rune(ellipsis)[0]) {
+		suffix = "..."
+	}
+	runes := []rune(strings.TrimRightFunc(r.text, unicode.IsSpace))
+	for n := len(runes); n >= 0; n-- {
+		text := strings.TrimRightFunc(string(runes[:n]), unicode.IsSpace) + suffix
+		if font.MeasureString(r.face, text) <= width || n == 0 {
+			return newTextRun(r.line, r.face, text)
+		}
+	}
+	return r
+}
+
+// drawRuns draws the text runs onto dst.
+func drawRuns(dst draw.Image, runs []textRun) {
+	for _, r := range runs {
+		drawRun(dst, r)
+	}
+}
+
+// Draw implements Layer. Without Layout, the lines are placed at their
+// positions relative to rect.
+func (btn TextButton) Draw(dst draw.Image, rect image.Rectangle) {
+	if btn.BgColor != nil {
+		draw.Draw(dst, rect, image.NewUniform(btn.BgColor), image.Point{}, draw.Over)
+	}
+	DrawText(dst, rect, btn.Lines, btn.Layout)
+}
+
+// DrawText draws the lines into rect of dst. If layout is nil, the lines
+// are placed at their PosX / PosY (relative to rect), otherwise they are
+// laid out automatically.
+func DrawText(dst draw.Image, rect image.Rectangle, lines []TextLine, layout *TextLayout) {
+	if layout == nil {
+		drawRuns(dst, absoluteRuns(rect, lines))
+		return
+	}
+	drawRuns(dst, layoutRuns(rect, lines, *layout))
+}
@@ -0,0 +1,129 @@
+package streamdeck
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"go.viam.com/test"
+	"golang.org/x/image/math/fixed"
+)
+
+// inkBounds returns the bounding box of all pixels which differ from bg.
+func inkBounds(img *image.RGBA, bg color.RGBA) image.Rectangle {
+	ink := image.Rectangle{}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if img.RGBAAt(x, y) != bg {
+				ink = ink.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return ink
+}
+
+func TestDrawTextBaselineFollowsFontSize(t *testing.T) {
+	black := color.RGBA{0, 0, 0, 255}
+	for _, size := range []float64{10, 24, 40} {
+		img := solidImage(120, black)
+		DrawText(img, img.Bounds(), []TextLine{{Text: "Hg", PosX: 0, PosY: 20, FontSize: size}}, nil)
+
+		// PosY is the top of the line, so the glyphs start right below it
+		ink := inkBounds(img, black)
+		test.That(t, ink.Min.Y, test.ShouldBeGreaterThanOrEqualTo, 20)
+		test.That(t, ink.Min.Y, test.ShouldBeLessThan, 20+int(size/3))
+		test.That(t, ink.Max.Y, test.ShouldBeLessThanOrEqualTo, 20+int(size*1.2))
+	}
+}
+
+func TestLayoutAlignment(t *testing.T) {
+	rect := image.Rect(0, 0, 72, 72)
+	lines := []TextLine{{Text: "Ab", FontSize: 20}}
+
+	runs := layoutRuns(rect, lines, TextLayout{HAlign: AlignCenter, VAlign: AlignMiddle})
+	test.That(t, len(runs), test.ShouldEqual, 1)
+	r := runs[0]
+	left := r.dot.X
+	right := fixed.I(72) - r.dot.X - r.width
+	test.That(t, max(left-right, right-left), test.ShouldBeLessThanOrEqualTo, fixed.I(1))
+	top := r.dot.Y - r.ascent
+	bottom := fixed.I(72) - top - r.height
+	test.That(t, max(top-bottom, bottom-top), test.ShouldBeLessThanOrEqualTo, fixed.I(1))
+
+	runs = layoutRuns(rect, lines, TextLayout{HAlign: AlignRight, VAlign: AlignBottom, Padding: 4})
+	r = runs[0]
+	test.That(t, r.dot.X+r.width, test.ShouldEqual, fixed.I(68))
+	test.That(t, r.dot.Y-r.ascent+r.height, test.ShouldEqual, fixed.I(68))
+}
+
+func TestLayoutWrapAndSpacing(t *testing.T) {
+	rect := image.Rect(0, 0, 72, 72)
+	lines := []TextLine{{Text: "one two three four", FontSize: 14}}
+
+	runs := layoutRuns(rect, lines, TextLayout{Wrap: true, LineSpacing: 1.5})
+	test.That(t, len(runs), test.ShouldBeGreaterThan, 1)
+	words := []string{}
+	for i, r := range runs {
+		test.That(t, r.width, test.ShouldBeLessThanOrEqualTo, fixed.I(72))
+		words = append(words, r.text)
+		if i > 0 {
+			test.That(t, r.dot.Y-runs[i-1].dot.Y, test.ShouldEqual, lineAdvance(runs[i-1], 1.5))
+		}
+	}
+	test.That(t, strings.Join(words, " "), test.ShouldEqual, "one two three four")
+
+	// words wider than the key are broken
+	runs = layoutRuns(rect, []TextLine{{Text: "abcdefghijklmnop", FontSize: 14}}, TextLayout{Wrap: true})
+	test.That(t, len(runs), test.ShouldBeGreaterThan, 1)
+	for _, r := range runs {
+		test.That(t, r.width, test.ShouldBeLessThanOrEqualTo, fixed.I(72))
+	}
+}
+
+func TestLayoutShrinkToFit(t *testing.T) {
+	rect := image.Rect(0, 0, 72, 72)
+	lines := []TextLine{{Text: "ABCDEFGH", FontSize: 30}}
+
+	runs := layoutRuns(rect, lines, TextLayout{MinFontSize: 8})
+	test.That(t, runs[0].width, test.ShouldBeLessThanOrEqualTo, fixed.I(72))
+	test.That(t, runs[0].text, test.ShouldEqual, "ABCDEFGH")
+
+	// the minimum size is not undercut, the rest is truncated
+	runs = layoutRuns(rect, lines, TextLayout{MinFontSize: 25, Ellipsis: true})
+	test.That(t, runs[0].width, test.ShouldBeLessThanOrEqualTo, fixed.I(72))
+	test.That(t, runs[0].text, test.ShouldNotEqual, "ABCDEFGH")
+	test.That(t, strings.HasSuffix(runs[0].text, "…") || strings.HasSuffix(runs[0].text, "..."), test.ShouldBeTrue)
+}
+
+func TestLayoutEllipsisDropsLines(t *testing.T) {
+	rect := image.Rect(0, 0, 72, 72)
+	lines := []TextLine{{Text: "a b c d e f g h i j k l m n o p q r s t", FontSize: 20}}
+
+	runs := layoutRuns(rect, lines, TextLayout{Wrap: true, Ellipsis: true})
+	test.That(t, blockHeight(runs, 1), test.ShouldBeLessThanOrEqualTo, fixed.I(72))
+	last := runs[len(runs)-1].text
+	test.That(t, strings.HasSuffix(last, "…") || strings.HasSuffix(last, "..."), test.ShouldBeTrue)
+}
+
+func TestMeasureText(t *testing.T) {
+	w1, h1 := MeasureText(nil, 12, "ab")
+	w2, h2 := MeasureText(nil, 12, "abcd")
+	test.That(t, w2, test.ShouldBeGreaterThan, w1)
+	test.That(t, h1, test.ShouldEqual, h2)
+	_, h3 := MeasureText(MonoMedium, 24, "ab")
+	test.That(t, h3, test.ShouldBeGreaterThan, h1)
+}
+
+func TestWriteTextWithLayout(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Original2)
+
+	err := sd.WriteText(0, TextButton{
+		BgColor: color.Black,
+		Lines:   []TextLine{{Text: "Volume up", FontSize: 18, FontColor: color.White}},
+		Layout:  &TextLayout{HAlign: AlignCenter, VAlign: AlignMiddle, Wrap: true, MinFontSize: 10},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, writtenKeys(ft), test.ShouldResemble, map[int]bool{0: true})
+}
@@ -0,0 +1,160 @@
+package streamdeck
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/disintegration/gift"
+	"golang.org/x/image/font"
+)
+
+// TextBackdrop is a rounded rectangle drawn behind a line of text.
+type TextBackdrop struct {
+	Color color.Color
+	// Padding (in pixel) between the text and the edge of the backdrop.
+	Padding int
+	// Radius (in pixel) of the corners.
+	Radius int
+	// Opacity (0 -> 1) of the backdrop. Zero is treated as fully opaque.
+	Opacity float64
+}
+
+// defaultShadowColor is used for drop shadows without a ShadowColor.
+var defaultShadowColor = color.NRGBA{0, 0, 0, 160}
+
+// drawRun draws a text run with its backdrop, shadow and outline.
+func drawRun(dst draw.Image, r textRun) {
+	line := r.line
+	opacity := normOpacity(line.Opacity)
+
+	fill := line.FontColor
+	if fill == nil {
+		fill = color.White
+	}
+
+	box := image.Rect(r.dot.X.Floor(), (r.dot.Y - r.ascent).Floor(),
+		(r.dot.X + r.width).Ceil(), (r.dot.Y - r.ascent + r.height).Ceil())
+
+	if b := line.Backdrop; b != nil && b.Color != nil {
+		rect := box.Inset(-b.Padding)
+		draw.DrawMask(dst, rect, image.NewUniform(fade(b.Color, normOpacity(b.Opacity)*opacity)),
+			image.Point{}, roundedRectMask(rect, b.Radius), rect.Min, draw.Over)
+	}
+
+	plain := line.StrokeWidth <= 0 && line.ShadowBlur <= 0 && line.ShadowOffset == (image.Point{})
+	if plain {
+		d := font.Drawer{Dst: dst, Src: image.NewUniform(fade(fill, opacity)), Face: r.face, Dot: r.dot}
+		d.DrawString(r.text)
+		return
+	}
+
+	// render the glyphs into a mask with enough room for outline and blur
+	margin := max(line.StrokeWidth, 0) + int(math.Ceil(3*float64(max(line.ShadowBlur, 0)))) + 1
+	area := box.Inset(-margin)
+	glyphs := image.NewAlpha(area)
+	d := font.Drawer{Dst: glyphs, Src: image.Opaque, Face: r.face, Dot: r.dot}
+	d.DrawString(r.text)
+
+	outline := glyphs
+	if line.StrokeWidth > 0 {
+		outline = dilate(glyphs, line.StrokeWidth)
+	}
+
+	if line.ShadowBlur > 0 || line.ShadowOffset != (image.Point{}) {
+		shadowColor := line.ShadowColor
+		if shadowColor == nil {
+			shadowColor = defaultShadowColor
+		}
+		var shadow image.Image = outline
+		if line.ShadowBlur > 0 {
+			g := gift.New(gift.GaussianBlur(line.ShadowBlur))
+			blurred := image.NewAlpha(g.Bounds(outline.Bounds()).Add(area.Min))
+			g.Draw(blurred, outline)
+			shadow = blurred
+		}
+		rect := area.Add(line.ShadowOffset)
+		draw.DrawMask(dst, rect, image.NewUniform(fade(shadowColor, opacity)),
+			image.Point{}, shadow, area.Min, draw.Over)
+	}
+
+	if line.StrokeWidth > 0 {
+		strokeColor := line.StrokeColor
+		if strokeColor == nil {
+			strokeColor = color.Black
+		}
+		draw.DrawMask(dst, area, image.NewUniform(fade(strokeColor, opacity)),
+			image.Point{}, outline, area.Min, draw.Over)
+	}
+
+	draw.DrawMask(dst, area, image.NewUniform(fade(fill, opacity)),
+		image.Point{}, glyphs, area.Min, draw.Over)
+}
+
+// normOpacity maps an opacity to 0 -> 1, treating zero as fully opaque.
+func normOpacity(o float64) float64 {
+	if o <= 0 || o > 1 {
+		return 1
+	}
+	return o
+}
+
+// fade multiplies the alpha of a color with the opacity.
+func fade(c color.Color, opacity float64) color.Color {
+	if opacity >= 1 {
+		return c
+	}
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	n.A = uint8(math.Round(float64(n.A) * opacity))
+	return n
+}
+
+// dilate grows the opaque areas of a mask by radius pixels.
+func dilate(mask *image.Alpha, radius int) *image.Alpha {
+	b := mask.Bounds()
+	res := image.NewAlpha(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			a := mask.AlphaAt(x, y).A
+			if a == 0 {
+				continue
+			}
+			for dy := -radius; dy <= radius; dy++ {
+				for dx := -radius; dx <= radius; dx++ {
+					if dx*dx+dy*dy > radius*radius {
+						continue
+					}
+					p := image.Pt(x+dx, y+dy)
+					if p.In(b) && res.AlphaAt(p.X, p.Y).A < a {
+						res.SetAlpha(p.X, p.Y, color.Alpha{a})
+					}
+				}
+			}
+		}
+	}
+	return res
+}
+
+// roundedRectMask returns an opaque mask of rect with rounded corners.
+func roundedRectMask(rect image.Rectangle, radius int) *image.Alpha {
+	mask := image.NewAlpha(rect)
+	radius = min(radius, rect.Dx()/2, rect.Dy()/2)
+	r := float64(radius)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			// distance of the pixel center to the rectangle inset by radius
+			px, py := float64(x)+0.5, float64(y)+0.5
+			cx := math.Min(math.Max(px, float64(rect.Min.X)+r), float64(rect.Max.X)-r)
+			cy := math.Min(math.Max(py, float64(rect.Min.Y)+r), float64(rect.Max.Y)-r)
+			dist := math.Hypot(px-cx, py-cy)
+			// antialias the edge over one pixel
+			a := 1.0
+			if dist > 0 {
+				a = math.Max(0, math.Min(1, r+0.5-dist))
+			}
+			mask.SetAlpha(x, y, color.Alpha{uint8(a * 255)})
+		}
+	}
+	return mask
+}
@@ -0,0 +1,86 @@
+package streamdeck
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+var black = color.RGBA{0, 0, 0, 255}
+
+func drawLine(line TextLine) *image.RGBA {
+	img := solidImage(120, black)
+	line.Text = "IH"
+	line.PosX, line.PosY = 30, 30
+	line.FontSize = 30
+	DrawText(img, img.Bounds(), []TextLine{line}, nil)
+	return img
+}
+
+// countPixels counts the pixels for which match returns true.
+func countPixels(img *image.RGBA, match func(c color.RGBA) bool) int {
+	n := 0
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if match(img.RGBAAt(x, y)) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func TestTextStroke(t *testing.T) {
+	plain := inkBounds(drawLine(TextLine{}), black)
+	img := drawLine(TextLine{StrokeWidth: 2, StrokeColor: color.RGBA{255, 0, 0, 255}})
+	stroked := inkBounds(img, black)
+
+	test.That(t, stroked.Min.X, test.ShouldEqual, plain.Min.X-2)
+	test.That(t, stroked.Max.X, test.ShouldEqual, plain.Max.X+2)
+	red := countPixels(img, func(c color.RGBA) bool { return c == color.RGBA{255, 0, 0, 255} })
+	test.That(t, red, test.ShouldBeGreaterThan, 0)
+	// the glyphs are drawn on top of the outline
+	white := countPixels(img, func(c color.RGBA) bool { return c == color.RGBA{255, 255, 255, 255} })
+	test.That(t, white, test.ShouldBeGreaterThan, 0)
+}
+
+func TestTextShadow(t *testing.T) {
+	plain := inkBounds(drawLine(TextLine{}), black)
+	blue := color.RGBA{0, 0, 255, 255}
+
+	img := drawLine(TextLine{ShadowOffset: image.Pt(3, 4), ShadowColor: blue})
+	shadowed := inkBounds(img, black)
+	test.That(t, shadowed.Min, test.ShouldResemble, plain.Min)
+	test.That(t, shadowed.Max, test.ShouldResemble, plain.Max.Add(image.Pt(3, 4)))
+	test.That(t, countPixels(img, func(c color.RGBA) bool { return c == blue }), test.ShouldBeGreaterThan, 0)
+
+	// blurring spreads the shadow beyond the offset glyphs
+	blurred := inkBounds(drawLine(TextLine{ShadowOffset: image.Pt(3, 4), ShadowColor: blue, ShadowBlur: 2}), black)
+	test.That(t, blurred.Max.X, test.ShouldBeGreaterThan, shadowed.Max.X)
+	test.That(t, blurred.Max.Y, test.ShouldBeGreaterThan, shadowed.Max.Y)
+}
+
+func TestTextBackdropAndOpacity(t *testing.T) {
+	img := drawLine(TextLine{
+		FontColor: color.Black,
+		Backdrop:  &TextBackdrop{Color: color.RGBA{0, 255, 0, 255}, Padding: 4, Radius: 6, Opacity: 0.5},
+	})
+	ink := inkBounds(img, black)
+	test.That(t, ink.Min.X, test.ShouldEqual, 30-4)
+
+	// the corners are rounded, the edges are translucent green
+	test.That(t, img.RGBAAt(ink.Min.X, ink.Min.Y), test.ShouldResemble, black)
+	mid := img.RGBAAt(ink.Min.X, (ink.Min.Y+ink.Max.Y)/2)
+	test.That(t, mid.G, test.ShouldBeBetween, 120, 136)
+
+	faded := drawLine(TextLine{Opacity: 0.5})
+	brightest := uint8(0)
+	countPixels(faded, func(c color.RGBA) bool {
+		brightest = max(brightest, c.R)
+		return false
+	})
+	test.That(t, brightest, test.ShouldBeBetween, 120, 136)
+}
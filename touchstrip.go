@@ -0,0 +1,130 @@
+package streamdeck
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+)
+
+// FillTouchStrip fills the whole LCD touch strip of the Stream Deck Plus
+// with an image. For best performance, provide the image in the native size
+// of the touch strip (800x100 pixels). Otherwise it will be automatically
+// resized.
+func (sd *StreamDeck) FillTouchStrip(img image.Image) error {
+	if err := sd.checkTouchStrip(); err != nil {
+		return err
+	}
+
+	rect := img.Bounds()
+	if rect.Dx() != sd.Config.TouchStripWidth || rect.Dy() != sd.Config.TouchStripHeight {
+		img = resize(img, sd.Config.TouchStripWidth, sd.Config.TouchStripHeight)
+	}
+
+	return sd.FillTouchStripRegion(0, 0, img)
+}
+
+// FillTouchStripRegion draws an image onto the LCD touch strip with its
+// upper left corner at the position x, y. The image is not resized and must
+// fit within the touch strip.
+func (sd *StreamDeck) FillTouchStripRegion(x, y int, img image.Image) error {
+	if err := sd.checkTouchStrip(); err != nil {
+		return err
+	}
+
+	rect := img.Bounds()
+	if x < 0 || y < 0 || rect.Dx() == 0 || rect.Dy() == 0 ||
+		x+rect.Dx() > sd.Config.TouchStripWidth ||
+		y+rect.Dy() > sd.Config.TouchStripHeight {
+		return fmt.Errorf("region %dx%d at %d,%d exceeds the touch strip", rect.Dx(), rect.Dy(), x, y)
+	}
+
+	imgBuf, err := encodeJPEG(img)
+	if err != nil {
+		return err
+	}
+
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+
+	if !sd.connected {
+		return ErrDisconnected
+	}
+	return sd.sendTouchStripInLock(x, y, rect.Dx(), rect.Dy(), imgBuf)
+}
+
+// FillTouchStripSegment fills the part of the LCD touch strip which is
+// located above the given dial. The image will be resized to the size
+// of the segment if necessary.
+func (sd *StreamDeck) FillTouchStripSegment(dialIndex int, img image.Image) error {
+	if err := sd.checkTouchStrip(); err != nil {
+		return err
+	}
+	if dialIndex < 0 || dialIndex >= sd.Config.NumDials {
+		return fmt.Errorf("invalid dial index")
+	}
+
+	rect := sd.Config.TouchStripSegment(dialIndex)
+	if img.Bounds().Dx() != rect.Dx() || img.Bounds().Dy() != rect.Dy() {
+		img = resize(img, rect.Dx(), rect.Dy())
+	}
+
+	return sd.FillTouchStripRegion(rect.Min.X, rect.Min.Y, img)
+}
+
+// TouchStripSegment returns the area of the LCD touch strip which is
+// located above the given dial.
+func (c *Config) TouchStripSegment(dialIndex int) image.Rectangle {
+	if c.NumDials == 0 {
+		return image.Rectangle{}
+	}
+	width := c.TouchStripWidth / c.NumDials
+	return image.Rect(dialIndex*width, 0, (dialIndex+1)*width, c.TouchStripHeight)
+}
+
+// sendTouchStripInLock sends a JPEG image in pages, each starting with a
+// 16 byte header containing the target region on the touch strip.
+func (sd *StreamDeck) sendTouchStripInLock(x, y, width, height int, imgBuf []byte) error {
+	reportLength := sd.Config.imageReportLength()
+	headerSize := 16
+	bytesLeft := len(imgBuf)
+	pos := 0
+	pageNumber := uint16(0)
+
+	for bytesLeft > 0 {
+		imgToSend := min(bytesLeft, reportLength-headerSize)
+
+		buf := make([]byte, reportLength)
+		bytesLeft -= imgToSend
+
+		buf[0] = 0x02
+		buf[1] = 0x0C
+		binary.LittleEndian.PutUint16(buf[2:], uint16(x))
+		binary.LittleEndian.PutUint16(buf[4:], uint16(y))
+		binary.LittleEndian.PutUint16(buf[6:], uint16(width))
+		binary.LittleEndian.PutUint16(buf[8:], uint16(height))
+		if bytesLeft == 0 {
+			buf[10] = 1
+		}
+		binary.LittleEndian.PutUint16(buf[11:], pageNumber)
+		binary.LittleEndian.PutUint16(buf[13:], uint16(imgToSend))
+
+		copy(buf[headerSize:], imgBuf[pos:(pos+imgToSend)])
+
+		if err := sd.writeInLock(buf); err != nil {
+			return err
+		}
+
+		pageNumber++
+		pos += imgToSend
+	}
+
+	return nil
+}
+
+// checkTouchStrip returns an error if the device has no touch strip.
+func (sd *StreamDeck) checkTouchStrip() error {
+	if !sd.Config.HasTouchStrip() {
+		return fmt.Errorf("device has no touch strip")
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package streamdeck
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestFillTouchStripSegment(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Plus)
+
+	img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	for x := 0; x < 200; x++ {
+		for y := 0; y < 100; y++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 0, 255})
+		}
+	}
+	err := sd.FillTouchStripSegment(1, img)
+	test.That(t, err, test.ShouldBeNil)
+
+	expected, err := encodeJPEG(img)
+	test.That(t, err, test.ShouldBeNil)
+
+	reports := ft.Written()
+	test.That(t, len(reports), test.ShouldEqual, (len(expected)+1007)/1008)
+
+	payload := []byte{}
+	for i, r := range reports {
+		test.That(t, len(r), test.ShouldEqual, 1024)
+		test.That(t, r[0], test.ShouldEqual, 0x02)
+		test.That(t, r[1], test.ShouldEqual, 0x0C)
+		test.That(t, binary.LittleEndian.Uint16(r[2:]), test.ShouldEqual, 200)
+		test.That(t, binary.LittleEndian.Uint16(r[4:]), test.ShouldEqual, 0)
+		test.That(t, binary.LittleEndian.Uint16(r[6:]), test.ShouldEqual, 200)
+		test.That(t, binary.LittleEndian.Uint16(r[8:]), test.ShouldEqual, 100)
+		test.That(t, binary.LittleEndian.Uint16(r[11:]), test.ShouldEqual, i)
+		if i == len(reports)-1 {
+			test.That(t, r[10], test.ShouldEqual, 1)
+		} else {
+			test.That(t, r[10], test.ShouldEqual, 0)
+		}
+		n := int(binary.LittleEndian.Uint16(r[13:]))
+		payload = append(payload, r[16:16+n]...)
+	}
+	test.That(t, payload, test.ShouldResemble, expected)
+}
+
+func TestFillTouchStripErrors(t *testing.T) {
+	sd, ft := newFakeStreamDeck(t, Plus)
+
+	img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	test.That(t, sd.FillTouchStripRegion(700, 0, img), test.ShouldNotBeNil)
+	test.That(t, sd.FillTouchStripRegion(-1, 0, img), test.ShouldNotBeNil)
+	test.That(t, sd.FillTouchStripSegment(4, img), test.ShouldNotBeNil)
+	test.That(t, len(ft.Written()), test.ShouldEqual, 0)
+
+	test.That(t, sd.FillTouchStrip(img), test.ShouldBeNil)
+	test.That(t, binary.LittleEndian.Uint16(ft.Written()[0][6:]), test.ShouldEqual, 800)
+
+	xl, _ := newFakeStreamDeck(t, XL)
+	test.That(t, xl.FillTouchStrip(img), test.ShouldNotBeNil)
+}
@@ -0,0 +1,58 @@
+package streamdeck
+
+import (
+	"github.com/bearsh/hid"
+)
+
+// Transport is the low level connection to a Stream Deck. The StreamDeck
+// object only talks to the device through this interface, which allows it
+// to run against real USB HID devices as well as against the FakeTransport.
+type Transport interface {
+	// Read blocks until an input report has been received from the device.
+	Read(b []byte) (int, error)
+	// Write sends an output report to the device.
+	Write(b []byte) (int, error)
+	// SendFeatureReport sends a feature report to the device. The first
+	// byte of b must contain the report ID.
+	SendFeatureReport(b []byte) (int, error)
+	// GetFeatureReport retrieves a feature report from the device. The first
+	// byte of b must contain the ID of the requested report.
+	GetFeatureReport(b []byte) (int, error)
+	// Close releases the connection to the device.
+	Close() error
+	// Serial returns the serial number of the device.
+	Serial() string
+}
+
+// hidTransport implements the Transport interface for a USB HID device.
+type hidTransport struct {
+	device *hid.Device
+}
+
+func newHIDTransport(device *hid.Device) *hidTransport {
+	return &hidTransport{device: device}
+}
+
+func (t *hidTransport) Read(b []byte) (int, error) {
+	return t.device.Read(b)
+}
+
+func (t *hidTransport) Write(b []byte) (int, error) {
+	return t.device.Write(b)
+}
+
+func (t *hidTransport) SendFeatureReport(b []byte) (int, error) {
+	return t.device.SendFeatureReport(b)
+}
+
+func (t *hidTransport) GetFeatureReport(b []byte) (int, error) {
+	return t.device.GetFeatureReport(b)
+}
+
+func (t *hidTransport) Close() error {
+	return t.device.Close()
+}
+
+func (t *hidTransport) Serial() string {
+	return t.device.Serial
+}
@@ -0,0 +1,294 @@
+package streamdeck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"sync"
+)
+
+// PanelDevice places a Stream Deck within a VirtualPanel.
+type PanelDevice struct {
+	Deck *StreamDeck
+	// Column and Row are the position of the device's top left key in the
+	// global key grid of the VirtualPanel.
+	Column int
+	Row    int
+	// X and Y are the position (in pixel) of the device's top left key in
+	// the coordinate space of the VirtualPanel. The distance to the
+	// neighbouring devices determines the gap between them.
+	X int
+	Y int
+}
+
+// keyRect returns the area of a local key in the panel's coordinate space.
+func (d PanelDevice) keyRect(key int) image.Rectangle {
+	c := d.Deck.Config
+	col := key % c.NumButtonColumns
+	row := key / c.NumButtonColumns
+	x := d.X + col*(c.ButtonSize+c.Spacer)
+	y := d.Y + row*(c.ButtonSize+c.Spacer)
+	return image.Rect(x, y, x+c.ButtonSize, y+c.ButtonSize)
+}
+
+// PanelEvent is a StateEvent of one of the devices of a VirtualPanel. For
+// key events, Which is the global key index. State is the state of the
+// originating device with its keys at their global key indices, so that
+// State.IsKeyDown(Which) works as expected; the keys of the other devices
+// are never down. Dials and the touch strip keep their device index.
+// Device is the index of the originating device.
+type PanelEvent struct {
+	Device int
+	StateEvent
+}
+
+// VirtualPanel combines several Stream Decks into one panel with one
+// global key index space and one coordinate space. Global key indices
+// are counted row by row over the combined key grid.
+type VirtualPanel struct {
+	devices []PanelDevice
+	columns int
+	rows    int
+	width   int
+	height  int
+}
+
+type panelKey struct {
+	device int
+	key    int
+}
+
+// NewVirtualPanel returns a VirtualPanel consisting of the given devices.
+// The devices must not overlap in the global key grid.
+func NewVirtualPanel(devices ...PanelDevice) (*VirtualPanel, error) {
+	if len(devices) == 0 {
+		return nil, fmt.Errorf("virtual panel needs at least one device")
+	}
+
+	vp := &VirtualPanel{devices: devices}
+	used := map[image.Point]bool{}
+
+	for _, d := range devices {
+		if d.Deck == nil {
+			return nil, fmt.Errorf("virtual panel device without stream deck")
+		}
+		if d.Column < 0 || d.Row < 0 || d.X < 0 || d.Y < 0 {
+			return nil, fmt.Errorf("virtual panel device %s has a negative position", d.Deck.Serial())
+		}
+		c := d.Deck.Config
+		for key := 0; key < c.NumButtons(); key++ {
+			p := image.Point{d.Column + key%c.NumButtonColumns, d.Row + key/c.NumButtonColumns}
+			if used[p] {
+				return nil, fmt.Errorf("virtual panel devices overlap at column %d, row %d", p.X, p.Y)
+			}
+			used[p] = true
+		}
+		vp.columns = max(vp.columns, d.Column+c.NumButtonColumns)
+		vp.rows = max(vp.rows, d.Row+c.NumButtonRows)
+		vp.width = max(vp.width, d.X+c.PanelWidth())
+		vp.height = max(vp.height, d.Y+c.PanelHeight())
+	}
+
+	return vp, nil
+}
+
+// NumButtonColumns is the number of columns of the global key grid.
+func (vp *VirtualPanel) NumButtonColumns() int {
+	return vp.columns
+}
+
+// NumButtonRows is the number of rows of the global key grid.
+func (vp *VirtualPanel) NumButtonRows() int {
+	return vp.rows
+}
+
+// NumButtons is the size of the global key index space. If the devices
+// don't cover the complete key grid, some indices have no key.
+func (vp *VirtualPanel) NumButtons() int {
+	return vp.columns * vp.rows
+}
+
+// PanelWidth is the total width (in pixel) of the panel's coordinate space.
+func (vp *VirtualPanel) PanelWidth() int {
+	return vp.width
+}
+
+// PanelHeight is the total height (in pixel) of the panel's coordinate space.
+func (vp *VirtualPanel) PanelHeight() int {
+	return vp.height
+}
+
+// Locate returns the Stream Deck and its local key index for a global key index.
+func (vp *VirtualPanel) Locate(globalKey int) (*StreamDeck, int, error) {
+	pk, err := vp.locate(globalKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	return vp.devices[pk.device].Deck, pk.key, nil
+}
+
+func (vp *VirtualPanel) locate(globalKey int) (panelKey, error) {
+	if globalKey < 0 || globalKey >= vp.NumButtons() {
+		return panelKey{}, fmt.Errorf("invalid key index")
+	}
+	col := globalKey % vp.columns
+	row := globalKey / vp.columns
+	for i, d := range vp.devices {
+		c := d.Deck.Config
+		if col >= d.Column && col < d.Column+c.NumButtonColumns &&
+			row >= d.Row && row < d.Row+c.NumButtonRows {
+			return panelKey{device: i, key: (row-d.Row)*c.NumButtonColumns + col - d.Column}, nil
+		}
+	}
+	return panelKey{}, fmt.Errorf("no key at index %d", globalKey)
+}
+
+// GlobalKey returns the global key index of a local key of a device.
+func (vp *VirtualPanel) GlobalKey(sd *StreamDeck, key int) (int, bool) {
+	for _, d := range vp.devices {
+		if d.Deck != sd {
+			continue
+		}
+		if key < 0 || key >= d.Deck.Config.NumButtons() {
+			return 0, false
+		}
+		return vp.globalKey(d, key), true
+	}
+	return 0, false
+}
+
+func (vp *VirtualPanel) globalKey(d PanelDevice, key int) int {
+	c := d.Deck.Config
+	col := d.Column + key%c.NumButtonColumns
+	row := d.Row + key/c.NumButtonColumns
+	return row*vp.columns + col
+}
+
+// KeyRect returns the area of a global key in the panel's coordinate space.
+func (vp *VirtualPanel) KeyRect(globalKey int) (image.Rectangle, error) {
+	pk, err := vp.locate(globalKey)
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	return vp.devices[pk.device].keyRect(pk.key), nil
+}
+
+// FillImage fills the key with the global key index with an image.
+func (vp *VirtualPanel) FillImage(globalKey int, img image.Image) error {
+	sd, key, err := vp.Locate(globalKey)
+	if err != nil {
+		return err
+	}
+	return sd.FillImage(key, img)
+}
+
+// FillPanel fills all devices of the panel with one image. The image is
+// scaled to cover the panel and then center-cropped (if necessary). The areas between
+// the keys and the gaps between the devices are not shown. See
+// FillPanelWithOptions for other fit modes.
+func (vp *VirtualPanel) FillPanel(img image.Image) error {
+	return vp.FillPanelWithOptions(img, PanelOptions{})
+}
+
+// FillPanelWithOptions fills all devices of the panel with one image,
+// fitted and sliced according to the PanelOptions. With IgnoreSpacer, the
+// image is sliced into adjacent tiles of the global key grid, ignoring the
+// gaps between the devices as well; the tiles have the size of the largest
+// keys. The images of all keys are written even if some of them fail; the
+// failed keys are reported as KeyError with their global key index.
+func (vp *VirtualPanel) FillPanelWithOptions(img image.Image, opts PanelOptions) error {
+	cell := 0
+	for _, d := range vp.devices {
+		cell = max(cell, d.Deck.Config.ButtonSize)
+	}
+	width, height := vp.width, vp.height
+	if opts.IgnoreSpacer {
+		width, height = vp.columns*cell, vp.rows*cell
+	}
+	canvas := fitImage(img, width, height, opts.Fit, opts.Background)
+
+	var errs []error
+	for _, d := range vp.devices {
+		c := d.Deck.Config
+		images := map[int][]byte{}
+		for key := 0; key < c.NumButtons(); key++ {
+			global := vp.globalKey(d, key)
+			r := d.keyRect(key)
+			if opts.IgnoreSpacer {
+				x, y := global%vp.columns*cell, global/vp.columns*cell
+				r = image.Rect(x, y, x+cell, y+cell)
+			}
+			tile := scaleImage(canvas.SubImage(r), c.ButtonSize, c.ButtonSize)
+			imgBuf, err := d.Deck.encodeImage(tile)
+			if err != nil {
+				errs = append(errs, &KeyError{Key: global, Err: err})
+				continue
+			}
+			images[key] = imgBuf
+		}
+
+		d.Deck.stopAnimations(imageKeys(images)...)
+		for _, err := range d.Deck.commitImages(images) {
+			var keyErr *KeyError
+			if errors.As(err, &keyErr) {
+				err = &KeyError{Key: vp.globalKey(d, keyErr.Key), Err: keyErr.Err}
+			} else {
+				err = fmt.Errorf("%s: %w", d.Deck.Serial(), err)
+			}
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+// globalSnapshot moves the keys of a device's snapshot to their global
+// key indices.
+func (vp *VirtualPanel) globalSnapshot(sd *StreamDeck, s Snapshot) Snapshot {
+	keys := make([]bool, vp.NumButtons())
+	for key, down := range s.keys {
+		if global, ok := vp.GlobalKey(sd, key); ok {
+			keys[global] = down
+		}
+	}
+	s.keys = keys
+	return s
+}
+
+// Events returns a channel on which the events of all devices are
+// delivered. Key indices of key events and of the states are translated
+// into global key indices. The channel is closed when ctx is cancelled or all devices
+// have been closed.
+func (vp *VirtualPanel) Events(ctx context.Context) <-chan PanelEvent {
+	out := make(chan PanelEvent, DefaultBufferSize)
+	wg := sync.WaitGroup{}
+
+	for i, d := range vp.devices {
+		events := d.Deck.Subscribe(ctx, SubscribeOptions{DropPolicy: Block})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range events {
+				switch e.Kind {
+				case EventKeyPressed, EventKeyReleased:
+					e.Which, _ = vp.GlobalKey(d.Deck, e.Which)
+				}
+				e.State = vp.globalSnapshot(d.Deck, e.State)
+				select {
+				case out <- PanelEvent{Device: i, StateEvent: e}:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
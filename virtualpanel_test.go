@@ -0,0 +1,200 @@
+package streamdeck
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+)
+
+func TestVirtualPanelKeys(t *testing.T) {
+	left, _ := newFakeStreamDeck(t, Original2)
+	right, _ := newFakeStreamDeck(t, Original2)
+
+	vp, err := NewVirtualPanel(
+		PanelDevice{Deck: left},
+		PanelDevice{Deck: right, Column: 5, X: Original2.PanelWidth() + 40},
+	)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, vp.NumButtonColumns(), test.ShouldEqual, 10)
+	test.That(t, vp.NumButtonRows(), test.ShouldEqual, 3)
+	test.That(t, vp.NumButtons(), test.ShouldEqual, 30)
+	test.That(t, vp.PanelWidth(), test.ShouldEqual, 2*Original2.PanelWidth()+40)
+	test.That(t, vp.PanelHeight(), test.ShouldEqual, Original2.PanelHeight())
+
+	sd, key, err := vp.Locate(7)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, sd, test.ShouldEqual, right)
+	test.That(t, key, test.ShouldEqual, 2)
+
+	sd, key, err = vp.Locate(12)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, sd, test.ShouldEqual, left)
+	test.That(t, key, test.ShouldEqual, 7)
+
+	_, _, err = vp.Locate(30)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	global, ok := vp.GlobalKey(right, 14)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, global, test.ShouldEqual, 29)
+
+	r, err := vp.KeyRect(5)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, r, test.ShouldResemble, image.Rect(476, 0, 548, 72))
+
+	_, err = NewVirtualPanel(PanelDevice{Deck: left}, PanelDevice{Deck: right, Column: 4})
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestVirtualPanelHoles(t *testing.T) {
+	xl, _ := newFakeStreamDeck(t, XL)
+	mini, _ := newFakeStreamDeck(t, Mini)
+
+	// the Mini is placed below the right half of the XL
+	vp, err := NewVirtualPanel(
+		PanelDevice{Deck: xl},
+		PanelDevice{Deck: mini, Column: 5, Row: 4, X: 500, Y: XL.PanelHeight() + 20},
+	)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, vp.NumButtons(), test.ShouldEqual, 8*6)
+
+	sd, key, err := vp.Locate(4*8 + 6)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, sd, test.ShouldEqual, mini)
+	test.That(t, key, test.ShouldEqual, 1)
+
+	_, _, err = vp.Locate(4 * 8)
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestVirtualPanelFill(t *testing.T) {
+	left, ftLeft := newFakeStreamDeck(t, Original2)
+	right, ftRight := newFakeStreamDeck(t, Original2)
+	ref, ftRef := newFakeStreamDeck(t, Original2)
+
+	vp, err := NewVirtualPanel(
+		PanelDevice{Deck: left},
+		PanelDevice{Deck: right, Column: 5, X: Original2.PanelWidth() + 40},
+	)
+	test.That(t, err, test.ShouldBeNil)
+
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	img := image.NewRGBA(image.Rect(0, 0, vp.PanelWidth(), vp.PanelHeight()))
+	draw.Draw(img, image.Rect(0, 0, vp.PanelWidth()/2, vp.PanelHeight()), image.NewUniform(red), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(vp.PanelWidth()/2, 0, vp.PanelWidth(), vp.PanelHeight()), image.NewUniform(blue), image.Point{}, draw.Src)
+
+	test.That(t, vp.FillPanel(img), test.ShouldBeNil)
+
+	test.That(t, ref.FillImage(0, solidImage(72, red)), test.ShouldBeNil)
+	redReports := ftRef.Written()
+	ftRef.Reset()
+	test.That(t, ref.FillImage(0, solidImage(72, blue)), test.ShouldBeNil)
+	blueReports := ftRef.Written()
+
+	keyReports := func(ft *FakeTransport, key byte) [][]byte {
+		res := [][]byte{}
+		for _, r := range ft.Written() {
+			if r[2] == key {
+				r[2] = 0
+				res = append(res, r)
+			}
+		}
+		return res
+	}
+	test.That(t, keyReports(ftLeft, 0), test.ShouldResemble, redReports)
+	test.That(t, keyReports(ftLeft, 14), test.ShouldResemble, redReports)
+	test.That(t, keyReports(ftRight, 4), test.ShouldResemble, blueReports)
+
+	ftLeft.Reset()
+	test.That(t, vp.FillImage(10, solidImage(72, blue)), test.ShouldBeNil)
+	test.That(t, keyReports(ftLeft, 5), test.ShouldResemble, blueReports)
+}
+
+func TestVirtualPanelFillWithOptions(t *testing.T) {
+	left, ftLeft := newFakeStreamDeck(t, Original2)
+	right, ftRight := newFakeStreamDeck(t, Original2)
+
+	vp, err := NewVirtualPanel(
+		PanelDevice{Deck: left},
+		PanelDevice{Deck: right, Column: 5, X: Original2.PanelWidth() + 40},
+	)
+	test.That(t, err, test.ShouldBeNil)
+
+	// a square image is letterboxed in the middle of the wide panel
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	opts := PanelOptions{Fit: FitLetterbox, Background: blue}
+	test.That(t, vp.FillPanelWithOptions(solidImage(100, red), opts), test.ShouldBeNil)
+
+	encoded := func(c color.Color) []byte {
+		imgBuf, err := left.encodeImage(solidImage(72, c))
+		test.That(t, err, test.ShouldBeNil)
+		return imgBuf
+	}
+	test.That(t, left.keyImages[0], test.ShouldResemble, encoded(blue))
+	test.That(t, left.keyImages[4], test.ShouldResemble, encoded(red))
+	test.That(t, right.keyImages[0], test.ShouldResemble, encoded(red))
+	test.That(t, right.keyImages[4], test.ShouldResemble, encoded(blue))
+
+	// without the spacers, a tiled key image ends up on every key
+	marked := solidImage(72, blue)
+	marked.Set(0, 0, red)
+	opts = PanelOptions{Fit: FitTile, IgnoreSpacer: true}
+	test.That(t, vp.FillPanelWithOptions(marked, opts), test.ShouldBeNil)
+	want, err := left.encodeImage(marked)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, left.keyImages[7], test.ShouldResemble, want)
+	test.That(t, right.keyImages[14], test.ShouldResemble, want)
+
+	// the keys of the connected device are written, the other device
+	// reports that it is disconnected
+	right.SetReconnectPolicy(ReconnectPolicy{Disabled: true})
+	ftRight.Close()
+	for right.Connected() {
+		time.Sleep(time.Millisecond)
+	}
+	ftLeft.Reset()
+	err = vp.FillPanel(solidImage(100, color.RGBA{0, 255, 0, 255}))
+	test.That(t, errors.Is(err, ErrDisconnected), test.ShouldBeTrue)
+	test.That(t, len(writtenKeys(ftLeft)), test.ShouldEqual, Original2.NumButtons())
+}
+
+func TestVirtualPanelEvents(t *testing.T) {
+	left, _ := newFakeStreamDeck(t, Original2)
+	right, ftRight := newFakeStreamDeck(t, Original2)
+
+	vp, err := NewVirtualPanel(
+		PanelDevice{Deck: left},
+		PanelDevice{Deck: right, Column: 5, X: 400},
+	)
+	test.That(t, err, test.ShouldBeNil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := vp.Events(ctx)
+
+	ftRight.Inject(keyReport(Original2, 2))
+	e := <-events
+	test.That(t, e.Device, test.ShouldEqual, 1)
+	test.That(t, e.String(), test.ShouldEqual, "key-pressed:7")
+	test.That(t, e.State.IsKeyDown(e.Which), test.ShouldBeTrue)
+	test.That(t, e.State.PressedKeys(), test.ShouldResemble, []int{7})
+
+	// the second row of the right device
+	ftRight.Inject(keyReport(Original2, 2, 6))
+	e = <-events
+	test.That(t, e.String(), test.ShouldEqual, "key-pressed:16")
+	test.That(t, e.State.IsKeyDown(e.Which), test.ShouldBeTrue)
+	test.That(t, e.State.PressedKeys(), test.ShouldResemble, []int{7, 16})
+	test.That(t, e.State.AllDown(1, 6), test.ShouldBeFalse)
+
+	cancel()
+	for range events {
+	}
+}